@@ -22,6 +22,8 @@ var (
 	optHelp = flag.Bool("h", false, "Show help")
 	optConf = flag.String("c", "./config.json", "Specify a config file")
 	optAddr = flag.String("a", ":9000", "Specify the address to listen on")
+	optPlan = flag.Bool("dry-run", false, "Print the planned changes of the records without applying them")
+	optForm = flag.String("plan-format", "", "Specify the format of the dry run plan: text or json")
 )
 
 func main() {
@@ -46,6 +48,19 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if *optPlan {
+		config.DryRun.Enabled = true
+	}
+
+	if *optForm != "" {
+		config.DryRun.Format = *optForm
+	}
+
+	// the dry run checks the records once
+	if config.DryRun.Enabled {
+		config.RunOnce = true
+	}
+
 	if err := utils.CheckSettings(&config); err != nil {
 		log.Fatal("Invalid settings: ", err.Error())
 	}
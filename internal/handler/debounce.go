@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/pkg/notification"
+)
+
+// defaultDebounceWindow is the window of the update limit and of the flapping events.
+const defaultDebounceWindow = time.Hour
+
+var errFlapping = errors.New("too many updates")
+
+// pendingIP is the new IP waiting for the confirmation.
+type pendingIP struct {
+	ip        string
+	firstSeen time.Time
+	polls     int
+}
+
+func debounceWindow(conf settings.Debounce) time.Duration {
+	if conf.Window > 0 {
+		return time.Duration(conf.Window) * time.Second
+	}
+	return defaultDebounceWindow
+}
+
+// confirmIP records the IP seen in the poll and reports whether it can be published.
+// The first IP is published at once, a change waits until the IP is seen long enough.
+func (handler *Handler) confirmIP(domain *settings.Domain, ip, cachedIP string, now time.Time) bool {
+	conf := handler.Configuration.Debounce
+	if !conf.Enabled || cachedIP == "" {
+		return true
+	}
+
+	handler.mutex.Lock()
+	if handler.pendingIPs == nil {
+		handler.pendingIPs = map[*settings.Domain]*pendingIP{}
+	}
+
+	var flap string
+	pending := handler.pendingIPs[domain]
+	if ip == cachedIP {
+		if pending != nil {
+			flap = fmt.Sprintf("IP of %s changed to %s and back to %s", domain.DomainName, pending.ip, ip)
+			delete(handler.pendingIPs, domain)
+		}
+		handler.mutex.Unlock()
+
+		if flap != "" {
+			handler.notifyFlapping(domain, flap, now)
+		}
+		return false
+	}
+
+	if pending == nil || pending.ip != ip {
+		if pending != nil {
+			flap = fmt.Sprintf("IP of %s changed from %s to %s before it was confirmed", domain.DomainName, pending.ip, ip)
+		}
+		pending = &pendingIP{ip: ip, firstSeen: now}
+		handler.pendingIPs[domain] = pending
+	}
+
+	pending.polls++
+	confirmed := pending.polls >= conf.ConfirmPolls && now.Sub(pending.firstSeen) >= time.Duration(conf.MinDuration)*time.Second
+	if confirmed {
+		delete(handler.pendingIPs, domain)
+	} else {
+		log.Printf("New IP %s of %s is pending, seen in %d polls since %s", ip, domain.DomainName, pending.polls, pending.firstSeen.Format(time.RFC3339))
+	}
+	handler.mutex.Unlock()
+
+	if flap != "" {
+		handler.notifyFlapping(domain, flap, now)
+	}
+
+	return confirmed
+}
+
+// allowUpdate reports whether the hostname can be updated in the current window,
+// only the updates recorded by recordUpdate are counted.
+func (handler *Handler) allowUpdate(hostname string, now time.Time) bool {
+	conf := handler.Configuration.Debounce
+	if !conf.Enabled || conf.MaxUpdates <= 0 {
+		return true
+	}
+
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	if handler.updateTimes == nil {
+		handler.updateTimes = map[string][]time.Time{}
+	}
+
+	window := debounceWindow(conf)
+	recent := handler.updateTimes[hostname][:0]
+	for _, t := range handler.updateTimes[hostname] {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+
+	handler.updateTimes[hostname] = recent
+	return len(recent) < conf.MaxUpdates
+}
+
+// recordUpdate counts the successful update of the hostname in the window.
+func (handler *Handler) recordUpdate(hostname string, now time.Time) {
+	conf := handler.Configuration.Debounce
+	if !conf.Enabled || conf.MaxUpdates <= 0 {
+		return
+	}
+
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	if handler.updateTimes == nil {
+		handler.updateTimes = map[string][]time.Time{}
+	}
+
+	handler.updateTimes[hostname] = append(handler.updateTimes[hostname], now)
+}
+
+// notifyFlapping sends the flapping event at most once in the window for the domain.
+func (handler *Handler) notifyFlapping(domain *settings.Domain, message string, now time.Time) {
+	log.Println(message)
+
+	handler.mutex.Lock()
+	if handler.flapEvents == nil {
+		handler.flapEvents = map[*settings.Domain]time.Time{}
+	}
+
+	last, ok := handler.flapEvents[domain]
+	if ok && now.Sub(last) < debounceWindow(handler.Configuration.Debounce) {
+		handler.mutex.Unlock()
+		return
+	}
+
+	handler.flapEvents[domain] = now
+	handler.mutex.Unlock()
+
+	handler.notificationManager.SendEvent(notification.EventFlapping, message)
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+type fakeNotificationManager struct {
+	events []string
+}
+
+func (m *fakeNotificationManager) Send(string, string) {}
+
+func (m *fakeNotificationManager) SendEvent(event, _ string) {
+	m.events = append(m.events, event)
+}
+
+func newDebounceHandler(debounce settings.Debounce) (*Handler, *fakeNotificationManager) {
+	manager := &fakeNotificationManager{}
+	return &Handler{
+		Configuration:       &settings.Settings{Debounce: debounce},
+		notificationManager: manager,
+	}, manager
+}
+
+func TestConfirmIP(t *testing.T) {
+	handler, manager := newDebounceHandler(settings.Debounce{Enabled: true, ConfirmPolls: 3})
+	domain := &settings.Domain{DomainName: "example.com"}
+	now := time.Now()
+
+	if !handler.confirmIP(domain, "203.0.113.1", "", now) {
+		t.Error("the first IP should be published at once")
+	}
+
+	for poll, expected := range []bool{false, false, true} {
+		if confirmed := handler.confirmIP(domain, "203.0.113.2", "203.0.113.1", now); confirmed != expected {
+			t.Errorf("poll %d: expected %v, got %v", poll+1, expected, confirmed)
+		}
+	}
+
+	// the uplink flaps back before the new IP is confirmed twice, only one event is sent
+	for i := 0; i < 2; i++ {
+		handler.confirmIP(domain, "203.0.113.3", "203.0.113.2", now)
+		if handler.confirmIP(domain, "203.0.113.2", "203.0.113.2", now) {
+			t.Error("unchanged IP should not be published")
+		}
+	}
+
+	if len(manager.events) != 1 {
+		t.Errorf("expected one flapping event, got %v", manager.events)
+	}
+}
+
+func TestAllowUpdate(t *testing.T) {
+	handler, _ := newDebounceHandler(settings.Debounce{Enabled: true, MaxUpdates: 2, Window: 60})
+	now := time.Now()
+	for i, expected := range []bool{true, true, false} {
+		allowed := handler.allowUpdate("www.example.com", now)
+		if allowed != expected {
+			t.Errorf("update %d: expected %v, got %v", i+1, expected, allowed)
+		}
+
+		if allowed {
+			handler.recordUpdate("www.example.com", now)
+		}
+	}
+
+	if !handler.allowUpdate("www.example.com", now.Add(time.Minute)) {
+		t.Error("update should be allowed after the window")
+	}
+}
+
+type failingProvider struct {
+	calls int
+}
+
+func (p *failingProvider) Init(*settings.Settings) {}
+
+func (p *failingProvider) UpdateIP(string, string, string) error {
+	p.calls++
+	return errors.New("provider is unavailable")
+}
+
+func TestFailedUpdateIsNotCounted(t *testing.T) {
+	handler, _ := newDebounceHandler(settings.Debounce{Enabled: true, MaxUpdates: 1, Window: 60})
+	fake := &failingProvider{}
+	handler.dnsProvider = fake
+
+	domain := &settings.Domain{DomainName: "example.com", SubDomains: []string{"www"}}
+	// the released records are updated without the lookup of the current ones
+	handler.setReleased("www.example.com", true)
+	for i := 0; i < 2; i++ {
+		if _, err := handler.updateRecord(domain, "www", "203.0.113.1"); err == nil || errors.Is(err, errFlapping) {
+			t.Fatalf("attempt %d: expected the error of the provider, got %v", i+1, err)
+		}
+	}
+
+	if fake.calls != 2 || !handler.allowUpdate("www.example.com", time.Now()) {
+		t.Errorf("failed updates should not be counted, provider called %d times", fake.calls)
+	}
+}
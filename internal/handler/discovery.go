@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"log"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pchchv/goddns/internal/provider"
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/state"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// reportDiscovery logs the domains which subdomains the provider cannot discover.
+func (handler *Handler) reportDiscovery() {
+	if _, ok := handler.dnsProvider.(provider.IRecordDiscoverer); ok {
+		return
+	}
+
+	for _, domain := range handler.Configuration.Domains {
+		if domain.Discovery.Enabled {
+			log.Printf("Provider %s does not support discovery, only the configured subdomains of %s are updated", handler.Configuration.Provider, domain.DomainName)
+		}
+	}
+}
+
+// discoverSubDomains finds the subdomains marked in the zone of the domain which are not configured.
+// The last discovered ones are kept if the zone cannot be read. A new subdomain resets the cached IP,
+// so it is published on this update.
+func (handler *Handler) discoverSubDomains(domain *settings.Domain) {
+	discoverer, ok := handler.dnsProvider.(provider.IRecordDiscoverer)
+	if !domain.Discovery.Enabled || !ok {
+		return
+	}
+
+	names, err := discoverer.DiscoverRecords(domain.DomainName, domain.Discovery.GetMarker())
+	if err != nil {
+		log.Printf("Failed to discover the subdomains of %s: %s", domain.DomainName, err)
+		return
+	}
+
+	var discovered []string
+	for _, name := range names {
+		hostname := utils.FQDN(domain.DomainName, name)
+		configured := slices.ContainsFunc(domain.SubDomains, func(sd string) bool { return utils.FQDN(domain.DomainName, sd) == hostname })
+		if !configured && !slices.Contains(discovered, name) {
+			discovered = append(discovered, name)
+		}
+	}
+	sort.Strings(discovered)
+
+	handler.mutex.Lock()
+	if handler.discovered == nil {
+		handler.discovered = map[string][]string{}
+	}
+
+	previous := handler.discovered[domain.DomainName]
+	handler.discovered[domain.DomainName] = discovered
+	handler.mutex.Unlock()
+
+	state.GetStore().SetDiscovered(domain.DomainName, discovered)
+	if slices.ContainsFunc(discovered, func(name string) bool { return !slices.Contains(previous, name) }) {
+		log.Printf("Discovered subdomains [ %s ] of %s", strings.Join(discovered, ", "), domain.DomainName)
+		handler.setCachedIP(domain, "")
+	}
+}
+
+// subDomains returns the configured subdomains of the domain followed by the discovered ones.
+func (handler *Handler) subDomains(domain *settings.Domain) []string {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	return append(slices.Clip(domain.SubDomains), handler.discovered[domain.DomainName]...)
+}
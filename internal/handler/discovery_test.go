@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/state"
+)
+
+type fakeRecordDiscoverer struct {
+	subdomains []string
+}
+
+func (p *fakeRecordDiscoverer) Init(*settings.Settings) {}
+
+func (p *fakeRecordDiscoverer) UpdateIP(string, string, string) error { return nil }
+
+func (p *fakeRecordDiscoverer) DiscoverRecords(string, string) ([]string, error) {
+	return p.subdomains, nil
+}
+
+func TestDiscoverSubDomains(t *testing.T) {
+	fake := &fakeRecordDiscoverer{subdomains: []string{"WWW", "ci", "@"}}
+	handler := &Handler{Configuration: &settings.Settings{}, dnsProvider: fake}
+	domain := &settings.Domain{DomainName: "discovery.example", SubDomains: []string{"www"}, Discovery: settings.Discovery{Enabled: true}}
+	handler.setCachedIP(domain, "203.0.113.1")
+
+	handler.discoverSubDomains(domain)
+	if subdomains := handler.subDomains(domain); !slices.Equal(subdomains, []string{"www", "@", "ci"}) {
+		t.Errorf("subdomains = %v", subdomains)
+	}
+
+	if handler.getCachedIP(domain) != "" {
+		t.Error("new subdomains should reset the cached IP")
+	}
+
+	if discovered := state.GetStore().Discovered("discovery.example"); !slices.Equal(discovered, []string{"@", "ci"}) {
+		t.Errorf("discovered subdomains in the store = %v", discovered)
+	}
+
+	handler.setCachedIP(domain, "203.0.113.1")
+	fake.subdomains = []string{"ci"}
+	handler.discoverSubDomains(domain)
+	if subdomains := handler.subDomains(domain); !slices.Equal(subdomains, []string{"www", "ci"}) || handler.getCachedIP(domain) == "" {
+		t.Errorf("removed subdomain should be dropped without the update: %v", subdomains)
+	}
+
+	if len(domain.SubDomains) != 1 {
+		t.Errorf("configured subdomains should be kept: %v", domain.SubDomains)
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/netip"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pchchv/goddns/internal/provider"
 	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/state"
 	"github.com/pchchv/goddns/internal/utils"
 	"github.com/pchchv/goddns/pkg/ip"
 	"github.com/pchchv/goddns/pkg/notification"
@@ -27,11 +31,29 @@ type Handler struct {
 	dnsProvider         provider.IDNSProvider
 	notificationManager notification.INotificationManager
 	ipManager           *ip.IPHelper
-	cachedIP            string
+	cachedIPs           map[*settings.Domain]string
+	cachedPrefixes      map[*settings.Domain]netip.Prefix
+	cachedHostIPs       map[string]string
+	pendingIPs          map[*settings.Domain]*pendingIP
+	updateTimes         map[string][]time.Time
+	flapEvents          map[*settings.Domain]time.Time
+	cachedRecords       map[string]string
+	plan                []PlanEntry
+	hosts               *state.Hosts
+	lostIPs             map[*settings.Domain]*lostIP
+	discovered          map[string][]string
+	verifications       sync.WaitGroup
+	mutex               sync.Mutex
 }
 
 func (handler *Handler) Init() {
 	handler.ipManager.UpdateConfiguration(handler.Configuration)
+	for _, wan := range handler.Configuration.WANs {
+		ip.GetWANIPHelper(handler.Configuration, wan.Name).UpdateConfiguration(handler.Configuration)
+	}
+
+	handler.reportLifecycle()
+	handler.reportDiscovery()
 }
 
 func (handler *Handler) SetConfiguration(conf *settings.Settings) {
@@ -48,10 +70,51 @@ func (handler *Handler) SetContext(ctx context.Context) {
 	handler.ctx = ctx
 }
 
+// SetHosts sets the store of the state of the hostnames kept across restarts.
+func (handler *Handler) SetHosts(hosts *state.Hosts) {
+	handler.hosts = hosts
+}
+
+func (handler *Handler) getHosts() *state.Hosts {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	if handler.hosts == nil {
+		handler.hosts, _ = state.LoadHosts("")
+	}
+
+	return handler.hosts
+}
+
 func (handler *Handler) UpdateIP(domain *settings.Domain) error {
-	ip := handler.ipManager.GetCurrentIP()
-	if ip == handler.cachedIP {
-		log.Printf("IP (%s) matches cached IP (%s), skipping", ip, handler.cachedIP)
+	handler.discoverSubDomains(domain)
+	if domain.RecordSet.Enabled {
+		return handler.updateRecordSets(domain)
+	}
+
+	ip, lostSince := detectedIP(handler.getIPHelper(domain))
+	handler.checkIPLost(domain, lostSince, time.Now())
+	cachedIP := handler.getCachedIP(domain)
+	if ip != "" && !handler.confirmIP(domain, ip, cachedIP, time.Now()) {
+		// the new IP is not confirmed yet, the records are kept as they are
+		ip = cachedIP
+	}
+
+	// the unchanged IP is sent again when the forced refresh is due
+	refresh := ip != "" && ip == cachedIP && handler.domainRefreshDue(domain, time.Now())
+	if ip == cachedIP && !refresh {
+		log.Printf("IP (%s) matches cached IP (%s), skipping", ip, cachedIP)
+		// the addresses of the neighbor hosts can change while the IP stays the same
+		if ip != "" && handler.Configuration.Prefix.Enabled {
+			if err := handler.updateLANHosts(domain, ip); err != nil {
+				log.Println("Failed to update LAN hosts:", err)
+			}
+		}
+
+		// the values of the extra records can change while the IP stays the same
+		if ip != "" {
+			handler.updateExtraRecords(domain, ip)
+		}
 		return nil
 	} else if ip == "" {
 		if handler.Configuration.RunOnce {
@@ -60,7 +123,18 @@ func (handler *Handler) UpdateIP(domain *settings.Domain) error {
 		return nil
 	}
 
-	if err := handler.updateDNS(domain, ip); err != nil {
+	err := handler.updateDNS(domain, ip)
+	if err == nil && handler.Configuration.Prefix.Enabled {
+		err = handler.updateLANHosts(domain, ip)
+	}
+
+	if errors.Is(err, errFlapping) {
+		// the IP is cached once all the records are updated
+		handler.notifyFlapping(domain, err.Error(), time.Now())
+		return nil
+	}
+
+	if err != nil {
 		if handler.Configuration.RunOnce {
 			return errors.New(err.Error() + ": fail to update DNS")
 		}
@@ -68,11 +142,86 @@ func (handler *Handler) UpdateIP(domain *settings.Domain) error {
 		return nil
 	}
 
-	handler.cachedIP = ip
+	handler.setCachedIP(domain, ip)
 	log.Printf("Cached IP address: %s", ip)
+	handler.updateExtraRecords(domain, ip)
 	return nil
 }
 
+// detectedIP returns the current IP of the helper and the time the detection fails since.
+// The last detected IP is not used while the detection fails.
+func detectedIP(helper *ip.IPHelper) (string, time.Time) {
+	currentIP := helper.GetCurrentIP()
+	if failedSince := helper.FailedSince(); !failedSince.IsZero() {
+		return "", failedSince
+	}
+
+	return currentIP, time.Time{}
+}
+
+// getIPHelper returns the IP helper of the WAN source the domain is mapped to.
+func (handler *Handler) getIPHelper(domain *settings.Domain) *ip.IPHelper {
+	if domain.WAN == "" {
+		return handler.ipManager
+	}
+
+	return ip.GetWANIPHelper(handler.Configuration, domain.WAN)
+}
+
+func (handler *Handler) getCachedIP(domain *settings.Domain) string {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	return handler.cachedIPs[domain]
+}
+
+func (handler *Handler) setCachedIP(domain *settings.Domain, ip string) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	if handler.cachedIPs == nil {
+		handler.cachedIPs = map[*settings.Domain]string{}
+	}
+
+	handler.cachedIPs[domain] = ip
+}
+
+func (handler *Handler) getCachedPrefix(domain *settings.Domain) netip.Prefix {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	return handler.cachedPrefixes[domain]
+}
+
+func (handler *Handler) setCachedPrefix(domain *settings.Domain, prefix netip.Prefix) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	if handler.cachedPrefixes == nil {
+		handler.cachedPrefixes = map[*settings.Domain]netip.Prefix{}
+	}
+
+	handler.cachedPrefixes[domain] = prefix
+}
+
+func (handler *Handler) getCachedHostIP(hostname string) string {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	return handler.cachedHostIPs[hostname]
+}
+
+func (handler *Handler) setCachedHostIP(hostname, ip string) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	if handler.cachedHostIPs == nil {
+		handler.cachedHostIPs = map[string]string{}
+	}
+
+	handler.cachedHostIPs[hostname] = ip
+}
+
 func (handler *Handler) LoopUpdateIP(ctx context.Context, domain *settings.Domain) error {
 	ticker := time.NewTicker(time.Second * time.Duration(handler.Configuration.Interval))
 	// run once at the beginning
@@ -100,43 +249,141 @@ func (handler *Handler) LoopUpdateIP(ctx context.Context, domain *settings.Domai
 
 func (handler *Handler) updateDNS(domain *settings.Domain, ip string) error {
 	var updatedDomains []string
-	for _, subdomainName := range domain.SubDomains {
-		var hostname string
-		if subdomainName != utils.RootDomain {
-			hostname = subdomainName + "." + domain.DomainName
-		} else {
-			hostname = domain.DomainName
+	var flapping error
+	for _, subdomainName := range handler.subDomains(domain) {
+		updated, err := handler.updateRecord(domain, subdomainName, ip)
+		if errors.Is(err, errFlapping) {
+			flapping = err
+			continue
+		} else if err != nil {
+			return err
 		}
 
-		lastIP, err := utils.ResolveDNS(hostname, handler.Configuration.Resolver, handler.Configuration.IPType)
-		if err != nil && (errors.Is(err, errEmptyResult) || errors.Is(err, errEmptyDomain)) {
-			log.Fatalf("Failed to resolve DNS for domain: %s, error: %s", hostname, err)
+		if updated {
+			updatedDomains = append(updatedDomains, subdomainName)
+		}
+	}
+
+	if len(updatedDomains) > 0 {
+		successMessage := fmt.Sprintf("[ %s ] of %s", strings.Join(updatedDomains, ", "), domain.DomainName)
+		handler.notificationManager.Send(successMessage, ip)
+	}
+
+	return flapping
+}
+
+// updateLANHosts publishes the addresses of the LAN hosts in the delegated prefix of the IP.
+// The static hosts are updated when the prefix changes,
+// the neighbor hosts are looked up every time as they can change their addresses.
+func (handler *Handler) updateLANHosts(domain *settings.Domain, currentIP string) error {
+	if len(domain.LANHosts) == 0 {
+		return nil
+	}
+
+	helper := handler.getIPHelper(domain)
+	prefix, err := helper.PrefixOf(currentIP)
+	if err != nil {
+		return err
+	}
+
+	prefixChanged := prefix != handler.getCachedPrefix(domain)
+	var updatedDomains []string
+	for _, host := range domain.LANHosts {
+		if !host.Neighbor && !prefixChanged {
 			continue
 		}
 
-		// check against the current known IP, if no change, skip update
-		if ip == lastIP {
-			log.Printf("IP is the same as cached one (%s). Skip update.", ip)
-		} else {
-			if err := handler.dnsProvider.UpdateIP(domain.DomainName, subdomainName, ip); err != nil {
-				return err
+		var addr netip.Addr
+		hostname := utils.FQDN(domain.DomainName, host.SubDomain)
+		if host.Neighbor {
+			if addr, err = helper.GetNeighborIP(host.MAC, prefix); err != nil {
+				// the device can be offline, its record is kept as is
+				log.Printf("Skip LAN host %s: %s", hostname, err)
+				continue
 			}
 
-			updatedDomains = append(updatedDomains, subdomainName)
-
-			// execute webhook when it is enabled
-			if handler.Configuration.Webhook.Enabled {
-				if err := webhook.GetWebhook(handler.Configuration).Execute(hostname, ip); err != nil {
-					return err
-				}
+			if !prefixChanged && handler.getCachedHostIP(hostname) == addr.String() {
+				continue
 			}
+		} else if addr, err = ip.HostAddress(prefix, host); err != nil {
+			return err
+		}
+
+		updated, err := handler.updateRecord(domain, host.SubDomain, addr.String())
+		if err != nil {
+			return err
+		}
+
+		handler.setCachedHostIP(hostname, addr.String())
+		if updated {
+			updatedDomains = append(updatedDomains, host.SubDomain)
 		}
 	}
 
 	if len(updatedDomains) > 0 {
 		successMessage := fmt.Sprintf("[ %s ] of %s", strings.Join(updatedDomains, ", "), domain.DomainName)
-		handler.notificationManager.Send(successMessage, ip)
+		handler.notificationManager.Send(successMessage, prefix.String())
 	}
 
+	handler.setCachedPrefix(domain, prefix)
 	return nil
 }
+
+// updateRecord updates the record of the subdomain if it does not match the IP yet.
+func (handler *Handler) updateRecord(domain *settings.Domain, subdomainName, ip string) (bool, error) {
+	hostname := utils.FQDN(domain.DomainName, subdomainName)
+
+	if handler.Configuration.DryRun.Enabled {
+		handler.planRecord(domain.DomainName, subdomainName, hostname, utils.RecordSetUpdate{IPs: []string{ip}, Mode: utils.RecordSetReplace})
+		return false, nil
+	}
+
+	if handler.isReleased(hostname) {
+		log.Printf("Records of %s were released, publishing %s again", hostname, ip)
+	} else if handler.refreshDue(domain, hostname, time.Now()) {
+		log.Printf("Forced refresh of %s is due, sending %s", hostname, ip)
+	} else if handler.Configuration.Authoritative {
+		// the authoritative servers see the update at once, unlike the caching resolvers
+		lastIPs, err := utils.ResolveAuthoritative(hostname, handler.Configuration.GetResolvers(), handler.Configuration.IPType)
+		if err != nil {
+			log.Printf("Failed to query the authoritative name servers of %s: %s", hostname, err)
+		} else if slices.Contains(lastIPs, ip) {
+			log.Printf("IP %s is served by the authoritative name servers of %s. Skip update.", ip, hostname)
+			return false, nil
+		}
+	} else {
+		lastIP, err := utils.ResolveDNS(hostname, handler.Configuration.GetResolvers(), handler.Configuration.IPType)
+		if err != nil && (errors.Is(err, errEmptyResult) || errors.Is(err, errEmptyDomain)) {
+			log.Fatalf("Failed to resolve DNS for domain: %s, error: %s", hostname, err)
+			return false, nil
+		}
+
+		// check against the current known IP, if no change, skip update
+		if ip == lastIP {
+			log.Printf("IP is the same as cached one (%s). Skip update.", ip)
+			return false, nil
+		}
+	}
+
+	if !handler.allowUpdate(hostname, time.Now()) {
+		return false, fmt.Errorf("%w of %s in %s, %s is not published", errFlapping, hostname, debounceWindow(handler.Configuration.Debounce), ip)
+	}
+
+	if err := handler.dnsProvider.UpdateIP(domain.DomainName, subdomainName, ip); err != nil {
+		return false, err
+	}
+
+	handler.recordUpdate(hostname, time.Now())
+	handler.setReleased(hostname, false)
+	handler.touchRefresh(domain, hostname, time.Now())
+
+	// execute webhook when it is enabled
+	if handler.Configuration.Webhook.Enabled {
+		if err := webhook.GetWebhook(handler.Configuration).Execute(hostname, ip); err != nil {
+			return true, err
+		}
+	}
+
+	handler.verifyUpdate(hostname, ip)
+	return true, nil
+}
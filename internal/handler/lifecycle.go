@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pchchv/goddns/internal/provider"
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// lostIP is the loss of the IP of the domain.
+type lostIP struct {
+	since    time.Time
+	released bool
+}
+
+// Release applies the on stop actions of the domains and removes the addresses
+// of the agent from the record sets.
+func (handler *Handler) Release() {
+	handler.RemoveRecordSets()
+	for i := range handler.Configuration.Domains {
+		domain := &handler.Configuration.Domains[i]
+		handler.releaseDomain(domain, domain.Lifecycle.OnStop)
+	}
+}
+
+// reportLifecycle logs the lifecycle options the provider does not support.
+func (handler *Handler) reportLifecycle() {
+	for _, domain := range handler.Configuration.Domains {
+		options := [][2]string{{"on_stop", domain.Lifecycle.OnStop}, {"on_ip_lost", domain.Lifecycle.OnIPLost}}
+		for _, option := range options {
+			if !handler.lifecycleSupported(&domain, option[1]) {
+				log.Printf("Provider %s does not support the %s action, the %s option of %s is not supported", handler.Configuration.Provider, option[1], option[0], domain.DomainName)
+			}
+		}
+	}
+}
+
+// lifecycleSupported reports whether the provider can apply the action to the records of the domain.
+func (handler *Handler) lifecycleSupported(domain *settings.Domain, action string) bool {
+	if action == "" || action == utils.LifecycleKeep {
+		return true
+	}
+
+	if domain.RecordSet.Enabled {
+		_, ok := handler.dnsProvider.(provider.IRecordSetProvider)
+		return ok
+	} else if action == utils.LifecycleDelete {
+		_, ok := handler.dnsProvider.(provider.IRecordDeleter)
+		return ok
+	}
+
+	return true
+}
+
+// checkIPLost releases the records of the domain once no IP is detected for the configured duration.
+// lostSince is the time the detection fails since, the zero time if the IP is detected.
+func (handler *Handler) checkIPLost(domain *settings.Domain, lostSince, now time.Time) {
+	lc := domain.Lifecycle
+	if lc.OnIPLost == "" || lc.OnIPLost == utils.LifecycleKeep {
+		return
+	}
+
+	handler.mutex.Lock()
+	if lostSince.IsZero() {
+		delete(handler.lostIPs, domain)
+		handler.mutex.Unlock()
+		return
+	}
+
+	if handler.lostIPs == nil {
+		handler.lostIPs = map[*settings.Domain]*lostIP{}
+	}
+
+	loss := handler.lostIPs[domain]
+	if loss == nil || !loss.since.Equal(lostSince) {
+		loss = &lostIP{since: lostSince}
+		handler.lostIPs[domain] = loss
+	}
+
+	due := !loss.released && now.Sub(loss.since) >= time.Duration(lc.IPLostAfter)*time.Second
+	if due {
+		loss.released = true
+	}
+	handler.mutex.Unlock()
+
+	if due {
+		log.Printf("No IP of %s is detected since %s", domain.DomainName, loss.since.Format(time.RFC3339))
+		handler.releaseDomain(domain, lc.OnIPLost)
+	}
+}
+
+// releaseDomain deletes or parks the records of the subdomains of the domain.
+// The records are published again on the next update with an IP.
+func (handler *Handler) releaseDomain(domain *settings.Domain, action string) {
+	if action == "" || action == utils.LifecycleKeep || handler.Configuration.DryRun.Enabled {
+		return
+	}
+
+	var released []string
+	for _, subdomainName := range handler.subDomains(domain) {
+		hostname := utils.FQDN(domain.DomainName, subdomainName)
+		if err := handler.releaseHost(domain, subdomainName, action); err != nil {
+			log.Printf("Failed to %s the records of %s: %s", action, hostname, err)
+			continue
+		}
+
+		handler.setReleased(hostname, true)
+		released = append(released, subdomainName)
+	}
+
+	if len(released) > 0 {
+		handler.setCachedIP(domain, "")
+		log.Printf("Records [ %s ] of %s released: %s", strings.Join(released, ", "), domain.DomainName, action)
+	}
+}
+
+// releaseHost deletes the records of the hostname or points them at the parking address.
+// Only the addresses of the agent are released from the record sets.
+func (handler *Handler) releaseHost(domain *settings.Domain, subdomainName, action string) error {
+	if !handler.lifecycleSupported(domain, action) {
+		return fmt.Errorf("provider %s does not support it", handler.Configuration.Provider)
+	}
+
+	var ips []string
+	if action == utils.LifecyclePark {
+		ips = []string{domain.Lifecycle.ParkingIP}
+	}
+
+	if domain.RecordSet.Enabled {
+		hostname := utils.FQDN(domain.DomainName, subdomainName)
+		update := utils.RecordSetUpdate{IPs: ips, Previous: handler.getPublishedIPs(hostname), Mode: utils.RecordSetMerge}
+		if err := handler.dnsProvider.(provider.IRecordSetProvider).UpdateRecordSet(domain.DomainName, subdomainName, update); err != nil {
+			return err
+		}
+
+		handler.setPublishedIPs(hostname, ips)
+		return nil
+	}
+
+	if action == utils.LifecyclePark {
+		return handler.dnsProvider.UpdateIP(domain.DomainName, subdomainName, domain.Lifecycle.ParkingIP)
+	}
+
+	return handler.dnsProvider.(provider.IRecordDeleter).DeleteRecords(domain.DomainName, subdomainName)
+}
+
+// isReleased reports whether the records of the hostname were released, also before a restart.
+func (handler *Handler) isReleased(hostname string) bool {
+	return handler.getHosts().Released(hostname)
+}
+
+func (handler *Handler) setReleased(hostname string, released bool) {
+	if err := handler.getHosts().SetReleased(hostname, released); err != nil {
+		log.Printf("Failed to save the released state of %s: %s", hostname, err)
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/state"
+	"github.com/pchchv/goddns/internal/utils"
+	"github.com/pchchv/goddns/pkg/ip"
+)
+
+type fakeRecordDeleter struct {
+	records map[string]string
+}
+
+func (p *fakeRecordDeleter) Init(*settings.Settings) {}
+
+func (p *fakeRecordDeleter) UpdateIP(_, subdomainName, ip string) error {
+	p.records[subdomainName] = ip
+	return nil
+}
+
+func (p *fakeRecordDeleter) DeleteRecords(_, subdomainName string) error {
+	delete(p.records, subdomainName)
+	return nil
+}
+
+func TestReleaseOnIPLost(t *testing.T) {
+	fake := &fakeRecordDeleter{records: map[string]string{"www": "203.0.113.1"}}
+	handler := &Handler{
+		Configuration:       &settings.Settings{},
+		dnsProvider:         fake,
+		notificationManager: &fakeNotificationManager{},
+	}
+
+	domain := &settings.Domain{DomainName: "example.com", SubDomains: []string{"www"}, Lifecycle: settings.Lifecycle{
+		OnIPLost:    utils.LifecycleDelete,
+		IPLostAfter: 60,
+	}}
+	handler.setCachedIP(domain, "203.0.113.1")
+
+	now := time.Now()
+	handler.checkIPLost(domain, now, now)
+	handler.checkIPLost(domain, now, now.Add(59*time.Second))
+	if _, ok := fake.records["www"]; !ok {
+		t.Fatal("records should be kept until the IP is lost long enough")
+	}
+
+	handler.checkIPLost(domain, now, now.Add(time.Minute))
+	if _, ok := fake.records["www"]; ok || handler.getCachedIP(domain) != "" {
+		t.Fatal("records should be deleted once the IP is lost long enough")
+	}
+
+	handler.checkIPLost(domain, time.Time{}, now.Add(2*time.Minute))
+	if updated, err := handler.updateRecord(domain, "www", "203.0.113.1"); err != nil || !updated {
+		t.Fatalf("released records should be published again: %v", err)
+	}
+
+	if fake.records["www"] != "203.0.113.1" || handler.isReleased("www.example.com") {
+		t.Errorf("records after the IP is back: %v", fake.records)
+	}
+}
+
+func TestUpdateIPReleasesOnIPLost(t *testing.T) {
+	fake := &fakeRecordDeleter{records: map[string]string{"www": "203.0.113.1"}}
+	conf := &settings.Settings{
+		IPInterface: "goddns-none0",
+		IPDetect:    settings.IPDetect{Sources: []settings.IPSource{{Type: utils.IPSourceInterface}}},
+	}
+	handler := &Handler{
+		Configuration:       conf,
+		dnsProvider:         fake,
+		notificationManager: &fakeNotificationManager{},
+		ipManager:           ip.NewIPHelper(conf),
+	}
+
+	domain := &settings.Domain{DomainName: "example.com", SubDomains: []string{"www"}, Lifecycle: settings.Lifecycle{
+		OnIPLost:    utils.LifecycleDelete,
+		IPLostAfter: 1,
+	}}
+	handler.setCachedIP(domain, "203.0.113.1")
+
+	// the interface does not exist, so the detection fails on every poll
+	if err := handler.UpdateIP(domain); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := fake.records["www"]; !ok {
+		t.Fatal("records should be kept until the IP is lost long enough")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if err := handler.UpdateIP(domain); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := fake.records["www"]; ok {
+		t.Error("records should be deleted once the IP is lost long enough")
+	}
+}
+
+func TestReleaseOnStop(t *testing.T) {
+	fake := &fakeRecordDeleter{records: map[string]string{"www": "203.0.113.1", "api": "203.0.113.1"}}
+	handler := &Handler{
+		Configuration: &settings.Settings{Domains: []settings.Domain{
+			{DomainName: "example.com", SubDomains: []string{"www"}, Lifecycle: settings.Lifecycle{OnStop: utils.LifecyclePark, ParkingIP: "192.0.2.1"}},
+			{DomainName: "example.org", SubDomains: []string{"api"}, Lifecycle: settings.Lifecycle{OnStop: utils.LifecycleDelete}},
+		}},
+		dnsProvider: fake,
+	}
+
+	handler.Release()
+	if _, ok := fake.records["api"]; fake.records["www"] != "192.0.2.1" || ok {
+		t.Errorf("records after the stop: %v", fake.records)
+	}
+
+	unsupported := &fakeRecordProvider{records: map[string]string{}}
+	handler.dnsProvider = unsupported
+	if handler.lifecycleSupported(&handler.Configuration.Domains[1], utils.LifecycleDelete) {
+		t.Error("delete should not be supported by the provider without deletion")
+	}
+
+	if !handler.lifecycleSupported(&handler.Configuration.Domains[0], utils.LifecyclePark) {
+		t.Error("park should be supported by every provider")
+	}
+}
+
+func TestReleaseBeforeRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	fake := &fakeRecordDeleter{records: map[string]string{"www": "203.0.113.1"}}
+	conf := &settings.Settings{Domains: []settings.Domain{
+		{DomainName: "example.com", SubDomains: []string{"www"}, Lifecycle: settings.Lifecycle{OnStop: utils.LifecycleDelete}},
+	}}
+
+	hosts, _ := state.LoadHosts(path)
+	before := &Handler{Configuration: conf, dnsProvider: fake}
+	before.SetHosts(hosts)
+	before.Release()
+	if _, ok := fake.records["www"]; ok {
+		t.Fatal("records should be deleted on stop")
+	}
+
+	// the resolvers can still return the old address after the restart,
+	// the released records are published without asking them
+	hosts, _ = state.LoadHosts(path)
+	after := &Handler{Configuration: conf, dnsProvider: fake, notificationManager: &fakeNotificationManager{}}
+	after.SetHosts(hosts)
+	if updated, err := after.updateRecord(&conf.Domains[0], "www", "203.0.113.1"); err != nil || !updated {
+		t.Fatalf("released records should be published after the restart: %v", err)
+	}
+
+	if fake.records["www"] != "203.0.113.1" || after.isReleased("www.example.com") {
+		t.Errorf("records after the restart: %v", fake.records)
+	}
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pchchv/goddns/internal/provider"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// PlanEntry is the change of the records of the hostname the update would make.
+type PlanEntry struct {
+	Hostname string   `json:"hostname"`
+	Type     string   `json:"type"`
+	Action   string   `json:"action"`
+	Current  []string `json:"current"`
+	Desired  []string `json:"desired"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// planRecord adds the change of the records of the hostname to the plan.
+// The records are read from the provider if it supports it, otherwise they are resolved.
+func (handler *Handler) planRecord(domainName, subdomainName, hostname string, update utils.RecordSetUpdate) {
+	recordType := utils.IPTypeA
+	if strings.ToUpper(handler.Configuration.IPType) == utils.IPV6 {
+		recordType = utils.IPTypeAAAA
+	}
+
+	entry := PlanEntry{Hostname: hostname, Type: recordType, Desired: update.IPs}
+	current, err := handler.currentRecords(domainName, subdomainName, hostname)
+	if err == nil {
+		entry.Desired = update.Apply(current)
+	}
+
+	switch {
+	case err != nil:
+		entry.Action, entry.Error = utils.PlanError, err.Error()
+	case len(current) == 0:
+		entry.Action = utils.PlanCreate
+	case slices.Equal(current, entry.Desired):
+		entry.Action = utils.PlanNoop
+	default:
+		entry.Action = utils.PlanUpdate
+	}
+
+	entry.Current = current
+	handler.mutex.Lock()
+	handler.plan = append(handler.plan, entry)
+	handler.mutex.Unlock()
+}
+
+// currentRecords returns the sorted addresses of the hostname.
+func (handler *Handler) currentRecords(domainName, subdomainName, hostname string) ([]string, error) {
+	var current []string
+	var err error
+	conf := handler.Configuration
+	if reader, ok := handler.dnsProvider.(provider.IRecordReader); ok {
+		current, err = reader.GetRecords(domainName, subdomainName)
+	} else if conf.Authoritative {
+		current, err = utils.ResolveAuthoritative(hostname, conf.GetResolvers(), conf.IPType)
+	} else {
+		var lastIP string
+		if lastIP, err = utils.ResolveDNS(hostname, conf.GetResolvers(), conf.IPType); err == nil {
+			current = []string{lastIP}
+		}
+	}
+
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+
+	sort.Strings(current)
+	return slices.Compact(current), nil
+}
+
+// isNotFound reports whether the lookup failed because the hostname has no records.
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsNotFound
+	} else if errors.Is(err, utils.ErrNoAuthoritativeAddress) {
+		return true
+	}
+
+	msg := err.Error()
+	return msg == errEmptyResult.Error() || msg == errEmptyDomain.Error() || strings.HasPrefix(msg, "cannot resolve domain")
+}
+
+// Plan returns the planned changes and reports whether any record drifts from the addresses.
+func (handler *Handler) Plan() (entries []PlanEntry, drift bool) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	entries = append(entries, handler.plan...)
+	for _, entry := range entries {
+		drift = drift || entry.Action != utils.PlanNoop
+	}
+
+	return entries, drift
+}
+
+// WritePlan writes the planned changes in the format.
+func WritePlan(w io.Writer, entries []PlanEntry, format string) error {
+	if format == utils.PlanFormatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	counts := map[string]int{}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, entry := range entries {
+		counts[entry.Action]++
+		change := strings.Join(entry.Desired, ",")
+		if len(entry.Current) > 0 {
+			change = strings.Join(entry.Current, ",") + " -> " + change
+		}
+
+		if entry.Error != "" {
+			change = entry.Error
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.Action, entry.Hostname, entry.Type, change)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "Plan: %d to create, %d to update, %d unchanged, %d failed.\n",
+		counts[utils.PlanCreate], counts[utils.PlanUpdate], counts[utils.PlanNoop], counts[utils.PlanError])
+	return err
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+type fakeRecordReader struct {
+	records map[string][]string
+	updates int
+}
+
+func (p *fakeRecordReader) Init(*settings.Settings) {}
+
+func (p *fakeRecordReader) UpdateIP(string, string, string) error {
+	p.updates++
+	return nil
+}
+
+func (p *fakeRecordReader) GetRecords(_, subdomainName string) ([]string, error) {
+	return p.records[subdomainName], nil
+}
+
+func TestDryRunPlan(t *testing.T) {
+	fake := &fakeRecordReader{records: map[string][]string{
+		"www":  {"203.0.113.1"},
+		"home": {"198.51.100.7"},
+	}}
+	handler := &Handler{
+		Configuration:       &settings.Settings{DryRun: settings.DryRun{Enabled: true}},
+		dnsProvider:         fake,
+		notificationManager: &fakeNotificationManager{},
+	}
+
+	domain := &settings.Domain{DomainName: "example.com", SubDomains: []string{"www", "home", "new"}}
+	if err := handler.updateDNS(domain, "203.0.113.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if fake.updates != 0 {
+		t.Errorf("dry run should not update the records, %d updates", fake.updates)
+	}
+
+	entries, drift := handler.Plan()
+	if !drift {
+		t.Error("plan should report the drift")
+	}
+
+	actions := map[string]string{}
+	for _, entry := range entries {
+		actions[entry.Hostname] = entry.Action
+	}
+
+	expected := map[string]string{
+		"www.example.com":  utils.PlanNoop,
+		"home.example.com": utils.PlanUpdate,
+		"new.example.com":  utils.PlanCreate,
+	}
+	for hostname, action := range expected {
+		if actions[hostname] != action {
+			t.Errorf("action of %s = %q, want %q", hostname, actions[hostname], action)
+		}
+	}
+
+	var text bytes.Buffer
+	if err := WritePlan(&text, entries, utils.PlanFormatText); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(text.String(), "198.51.100.7 -> 203.0.113.1") || !strings.Contains(text.String(), "1 to create, 1 to update, 1 unchanged") {
+		t.Errorf("unexpected text plan:\n%s", text.String())
+	}
+
+	var out bytes.Buffer
+	if err := WritePlan(&out, entries, utils.PlanFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []PlanEntry
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || len(decoded) != 3 {
+		t.Errorf("unexpected JSON plan %s: %v", out.String(), err)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	for _, tc := range []struct {
+		err      error
+		notFound bool
+	}{
+		{fmt.Errorf("%w: www.example.com", utils.ErrNoAuthoritativeAddress), true},
+		{errEmptyResult, true},
+		{errors.New("i/o timeout"), false},
+	} {
+		if got := isNotFound(tc.err); got != tc.notFound {
+			t.Errorf("isNotFound(%q) = %v, expected %v", tc.err, got, tc.notFound)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"bytes"
+	"log"
+	"text/template"
+	"time"
+
+	"github.com/pchchv/goddns/internal/provider"
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// recordData is the data of the value templates of the extra records.
+type recordData struct {
+	CurrentIP string
+	Hostname  string
+	Domain    string
+	AgentID   string
+	Timestamp string
+}
+
+// updateExtraRecords publishes the extra records of the domain for the current IP on every poll.
+// The records are updated only if their values change, a failed record is sent again on the next poll.
+func (handler *Handler) updateExtraRecords(domain *settings.Domain, currentIP string) {
+	if len(domain.Records) == 0 {
+		return
+	} else if handler.Configuration.DryRun.Enabled {
+		log.Printf("Dry run, the extra records of %s are not planned", domain.DomainName)
+		return
+	}
+
+	recordProvider, ok := handler.dnsProvider.(provider.IRecordProvider)
+	if !ok {
+		log.Printf("Provider %s does not support extra records, the records of %s are not published", handler.Configuration.Provider, domain.DomainName)
+		return
+	}
+
+	now := time.Now()
+	for _, record := range domain.Records {
+		hostname := utils.FQDN(domain.DomainName, record.SubDomain)
+
+		value, err := renderRecord(record.Value, recordData{
+			CurrentIP: currentIP,
+			Hostname:  hostname,
+			Domain:    domain.DomainName,
+			AgentID:   handler.Configuration.GetAgentID(),
+			Timestamp: now.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			log.Printf("Failed to render the %s record of %s: %s", record.Type, hostname, err)
+			continue
+		}
+
+		key := record.Type + " " + hostname
+		if handler.getCachedRecord(key) == value {
+			continue
+		}
+
+		if err = recordProvider.UpsertRecord(domain.DomainName, record.SubDomain, record.Type, value, record.TTL); err != nil {
+			log.Printf("Failed to publish the %s record of %s: %s", record.Type, hostname, err)
+			handler.deleteCachedRecord(key)
+			continue
+		}
+
+		handler.setCachedRecord(key, value)
+	}
+}
+
+func renderRecord(value string, data recordData) (string, error) {
+	t, err := template.New("record template").Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	var tpl bytes.Buffer
+	if err = t.Execute(&tpl, data); err != nil {
+		return "", err
+	}
+
+	return tpl.String(), nil
+}
+
+func (handler *Handler) getCachedRecord(key string) string {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	return handler.cachedRecords[key]
+}
+
+func (handler *Handler) setCachedRecord(key, value string) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	if handler.cachedRecords == nil {
+		handler.cachedRecords = map[string]string{}
+	}
+
+	handler.cachedRecords[key] = value
+}
+
+func (handler *Handler) deleteCachedRecord(key string) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	delete(handler.cachedRecords, key)
+}
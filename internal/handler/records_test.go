@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+type fakeRecordProvider struct {
+	records map[string]string
+	upserts int
+	failing bool
+}
+
+func (p *fakeRecordProvider) Init(*settings.Settings) {}
+
+func (p *fakeRecordProvider) UpdateIP(string, string, string) error { return nil }
+
+func (p *fakeRecordProvider) UpsertRecord(domainName, subdomainName, recordType, value string, _ int) error {
+	p.upserts++
+	if p.failing {
+		return errors.New("internal error")
+	}
+
+	p.records[recordType+" "+subdomainName] = value
+	return nil
+}
+
+func TestUpdateExtraRecords(t *testing.T) {
+	fake := &fakeRecordProvider{records: map[string]string{}}
+	handler := &Handler{
+		Configuration: &settings.Settings{AgentID: "agent-1"},
+		dnsProvider:   fake,
+	}
+
+	domain := &settings.Domain{
+		DomainName: "example.com",
+		Records: []settings.ExtraRecord{
+			{SubDomain: "_heartbeat", Type: "TXT", Value: "{{.AgentID}} {{.Timestamp}}"},
+			{SubDomain: "alias", Type: "CNAME", Value: "home.{{.Domain}}"},
+			{SubDomain: "home", Type: "HTTPS", Value: "1 . alpn=h2 ipv4hint={{.CurrentIP}}"},
+		},
+	}
+
+	handler.updateExtraRecords(domain, "203.0.113.1")
+	if got := fake.records["CNAME alias"]; got != "home.example.com" {
+		t.Errorf("CNAME record = %q", got)
+	}
+
+	if got := fake.records["HTTPS home"]; got != "1 . alpn=h2 ipv4hint=203.0.113.1" {
+		t.Errorf("HTTPS record = %q", got)
+	}
+
+	if got := fake.records["TXT _heartbeat"]; !strings.HasPrefix(got, "agent-1 ") {
+		t.Errorf("TXT record = %q", got)
+	}
+
+	// the CNAME is not changed, only the hint and the heartbeat are updated
+	fake.upserts = 0
+	handler.updateExtraRecords(domain, "203.0.113.2")
+	if fake.upserts > 2 {
+		t.Errorf("unchanged record should not be updated, %d upserts", fake.upserts)
+	}
+
+	if got := fake.records["HTTPS home"]; got != "1 . alpn=h2 ipv4hint=203.0.113.2" {
+		t.Errorf("HTTPS record = %q", got)
+	}
+}
+
+func TestUpdateExtraRecordsRetry(t *testing.T) {
+	fake := &fakeRecordProvider{records: map[string]string{}}
+	handler := &Handler{Configuration: &settings.Settings{}, dnsProvider: fake}
+	domain := &settings.Domain{
+		DomainName: "example.com",
+		Records:    []settings.ExtraRecord{{SubDomain: "home", Type: "HTTPS", Value: "1 . ipv4hint={{.CurrentIP}}"}},
+	}
+
+	handler.updateExtraRecords(domain, "203.0.113.1")
+	fake.failing = true
+	handler.updateExtraRecords(domain, "203.0.113.2")
+
+	// the state of the failed record is unknown, so it is sent again
+	fake.failing, fake.upserts = false, 0
+	handler.updateExtraRecords(domain, "203.0.113.1")
+	if fake.upserts != 1 {
+		t.Errorf("failed record should be sent again, %d upserts", fake.upserts)
+	}
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pchchv/goddns/internal/provider"
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+	"github.com/pchchv/goddns/pkg/ip"
+	"github.com/pchchv/goddns/pkg/webhook"
+)
+
+// recordSetIPs returns the sorted addresses of the WANs of the record set of the domain
+// and the time the detection fails since on all of them, the zero time if any IP is detected.
+func (handler *Handler) recordSetIPs(domain *settings.Domain) ([]string, time.Time) {
+	if len(domain.RecordSet.WANs) == 0 {
+		currentIP, lostSince := detectedIP(handler.getIPHelper(domain))
+		if currentIP == "" {
+			return nil, lostSince
+		}
+		return []string{currentIP}, time.Time{}
+	}
+
+	var ips []string
+	var lostSince time.Time
+	for _, wan := range domain.RecordSet.WANs {
+		currentIP, failedSince := detectedIP(ip.GetWANIPHelper(handler.Configuration, wan))
+		if currentIP != "" {
+			ips = append(ips, currentIP)
+			continue
+		}
+
+		log.Printf("No IP of WAN %s for the record set of %s", wan, domain.DomainName)
+		if failedSince.After(lostSince) {
+			lostSince = failedSince
+		}
+	}
+
+	if len(ips) > 0 {
+		lostSince = time.Time{}
+	}
+
+	sort.Strings(ips)
+	return slices.Compact(ips), lostSince
+}
+
+// updateRecordSets publishes the addresses of the record set for the subdomains of the domain,
+// the addresses go through the same confirmation, update limit, verification and refresh as a single IP.
+func (handler *Handler) updateRecordSets(domain *settings.Domain) error {
+	rsProvider, ok := handler.dnsProvider.(provider.IRecordSetProvider)
+	if !ok && !handler.Configuration.DryRun.Enabled {
+		log.Printf("Provider %s does not support record sets, %s is not updated", handler.Configuration.Provider, domain.DomainName)
+		return nil
+	}
+
+	ips, lostSince := handler.recordSetIPs(domain)
+	handler.checkIPLost(domain, lostSince, time.Now())
+	if len(ips) == 0 {
+		if handler.Configuration.RunOnce {
+			return errors.New("fail to get current IP")
+		}
+		return nil
+	}
+
+	key, cachedKey := strings.Join(ips, ","), handler.getCachedIP(domain)
+	if !handler.confirmIP(domain, key, cachedKey, time.Now()) {
+		// the new addresses are not confirmed yet, the record sets are kept as they are
+		key = cachedKey
+	}
+
+	// the unchanged addresses are sent again when the forced refresh is due
+	refresh := key == cachedKey && handler.domainRefreshDue(domain, time.Now())
+	if key == cachedKey && !refresh {
+		log.Printf("IPs (%s) match cached IPs, skipping", key)
+		handler.updateExtraRecords(domain, key)
+		return nil
+	}
+
+	ips = strings.Split(key, ",")
+	var updatedDomains []string
+	var flapping error
+	for _, subdomainName := range handler.subDomains(domain) {
+		updated, err := handler.updateRecordSet(rsProvider, domain, subdomainName, ips)
+		if errors.Is(err, errFlapping) {
+			flapping = err
+			continue
+		} else if err != nil {
+			if handler.Configuration.RunOnce {
+				return errors.New(err.Error() + ": fail to update DNS")
+			}
+			log.Printf("Failed to update the record set of %s: %s", utils.FQDN(domain.DomainName, subdomainName), err)
+			return nil
+		}
+
+		if updated {
+			updatedDomains = append(updatedDomains, subdomainName)
+		}
+	}
+
+	if len(updatedDomains) > 0 {
+		successMessage := fmt.Sprintf("[ %s ] of %s", strings.Join(updatedDomains, ", "), domain.DomainName)
+		handler.notificationManager.Send(successMessage, key)
+	}
+
+	if flapping != nil {
+		// the addresses are cached once all the record sets are updated
+		handler.notifyFlapping(domain, flapping.Error(), time.Now())
+		return nil
+	}
+
+	handler.setCachedIP(domain, key)
+	log.Printf("Cached IP addresses: %s", key)
+	handler.updateExtraRecords(domain, key)
+	return nil
+}
+
+// updateRecordSet publishes the addresses in the record set of the subdomain.
+func (handler *Handler) updateRecordSet(rsProvider provider.IRecordSetProvider, domain *settings.Domain, subdomainName string, ips []string) (bool, error) {
+	hostname := utils.FQDN(domain.DomainName, subdomainName)
+	key := strings.Join(ips, ",")
+
+	mode := domain.RecordSet.Mode
+	if mode == "" {
+		mode = utils.RecordSetMerge
+	}
+
+	if handler.Configuration.DryRun.Enabled {
+		handler.planRecord(domain.DomainName, subdomainName, hostname, utils.RecordSetUpdate{IPs: ips, Mode: mode})
+		return false, nil
+	}
+
+	if handler.isReleased(hostname) {
+		log.Printf("Record set of %s was released, publishing %s again", hostname, key)
+	} else if handler.refreshDue(domain, hostname, time.Now()) {
+		log.Printf("Forced refresh of %s is due, sending %s", hostname, key)
+	}
+
+	if !handler.allowUpdate(hostname, time.Now()) {
+		return false, fmt.Errorf("%w of %s in %s, %s is not published", errFlapping, hostname, debounceWindow(handler.Configuration.Debounce), key)
+	}
+
+	update := utils.RecordSetUpdate{IPs: ips, Previous: handler.getPublishedIPs(hostname), Mode: mode}
+	if err := rsProvider.UpdateRecordSet(domain.DomainName, subdomainName, update); err != nil {
+		return false, err
+	}
+
+	handler.recordUpdate(hostname, time.Now())
+	handler.setPublishedIPs(hostname, ips)
+	handler.setReleased(hostname, false)
+	handler.touchRefresh(domain, hostname, time.Now())
+
+	if handler.Configuration.Webhook.Enabled {
+		if err := webhook.GetWebhook(handler.Configuration).Execute(hostname, key); err != nil {
+			log.Printf("Failed to execute the webhook of %s: %s", hostname, err)
+		}
+	}
+
+	// each address of the agent has to be served in the record set
+	for _, ip := range ips {
+		handler.verifyUpdate(hostname, ip)
+	}
+
+	return true, nil
+}
+
+// RemoveRecordSets removes the addresses published by the agent from the record sets
+// of the domains which are configured to do so on shutdown.
+func (handler *Handler) RemoveRecordSets() {
+	rsProvider, ok := handler.dnsProvider.(provider.IRecordSetProvider)
+	if !ok {
+		return
+	}
+
+	for _, domain := range handler.Configuration.Domains {
+		if !domain.RecordSet.Enabled || !domain.RecordSet.RemoveOnStop {
+			continue
+		}
+
+		for _, subdomainName := range handler.subDomains(&domain) {
+			hostname := utils.FQDN(domain.DomainName, subdomainName)
+
+			ips := handler.getPublishedIPs(hostname)
+			if len(ips) == 0 {
+				continue
+			}
+
+			update := utils.RecordSetUpdate{IPs: ips, Mode: utils.RecordSetRemove}
+			if err := rsProvider.UpdateRecordSet(domain.DomainName, subdomainName, update); err != nil {
+				log.Printf("Failed to remove %s from the record set of %s: %s", strings.Join(ips, ","), hostname, err)
+				continue
+			}
+
+			handler.setPublishedIPs(hostname, nil)
+			log.Printf("Removed %s from the record set of %s", strings.Join(ips, ","), hostname)
+		}
+	}
+}
+
+func (handler *Handler) getPublishedIPs(hostname string) []string {
+	return handler.getHosts().Published(hostname)
+}
+
+func (handler *Handler) setPublishedIPs(hostname string, ips []string) {
+	if err := handler.getHosts().SetPublished(hostname, ips); err != nil {
+		log.Printf("Failed to save the published addresses of %s: %s", hostname, err)
+	}
+}
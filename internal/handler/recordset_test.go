@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/state"
+	"github.com/pchchv/goddns/internal/utils"
+	"github.com/pchchv/goddns/pkg/ip"
+	"github.com/pchchv/goddns/pkg/notification"
+)
+
+type fakeRecordSetProvider struct {
+	updates []utils.RecordSetUpdate
+}
+
+func (p *fakeRecordSetProvider) Init(*settings.Settings) {}
+
+func (p *fakeRecordSetProvider) UpdateIP(string, string, string) error { return nil }
+
+func (p *fakeRecordSetProvider) UpdateRecordSet(_, _ string, update utils.RecordSetUpdate) error {
+	p.updates = append(p.updates, update)
+	return nil
+}
+
+func TestRemoveRecordSetsAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	hosts, _ := state.LoadHosts(path)
+	before := &Handler{Configuration: &settings.Settings{}}
+	before.SetHosts(hosts)
+	before.setPublishedIPs("www.example.com", []string{"203.0.113.1"})
+
+	// the published addresses are loaded from the state file after the restart
+	fake := &fakeRecordSetProvider{}
+	hosts, _ = state.LoadHosts(path)
+	after := &Handler{
+		Configuration: &settings.Settings{Domains: []settings.Domain{{
+			DomainName: "example.com",
+			SubDomains: []string{"www"},
+			RecordSet:  settings.RecordSet{Enabled: true, RemoveOnStop: true},
+		}}},
+		dnsProvider: fake,
+	}
+	after.SetHosts(hosts)
+	after.RemoveRecordSets()
+
+	if len(fake.updates) != 1 || !slices.Equal(fake.updates[0].IPs, []string{"203.0.113.1"}) || fake.updates[0].Mode != utils.RecordSetRemove {
+		t.Fatalf("addresses published before the restart should be removed: %+v", fake.updates)
+	}
+
+	if ips := after.getPublishedIPs("www.example.com"); len(ips) != 0 {
+		t.Errorf("removed addresses should be forgotten: %v", ips)
+	}
+}
+
+// newRecordSetHandler returns the handler of the record set detecting ip by a local echo service.
+func newRecordSetHandler(t *testing.T, conf *settings.Settings, address string) (*Handler, *fakeRecordSetProvider, *fakeNotificationManager) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, address)
+	}))
+	t.Cleanup(server.Close)
+
+	conf.IPType = utils.IPV4
+	conf.IPUrls = []settings.IPURL{{URL: server.URL}}
+	fake, manager := &fakeRecordSetProvider{}, &fakeNotificationManager{}
+	return &Handler{
+		Configuration:       conf,
+		dnsProvider:         fake,
+		notificationManager: manager,
+		ipManager:           ip.NewIPHelper(conf),
+	}, fake, manager
+}
+
+func TestUpdateRecordSetsRefresh(t *testing.T) {
+	handler, fake, _ := newRecordSetHandler(t, &settings.Settings{RefreshDays: 1}, "203.0.113.1")
+	domain := &settings.Domain{DomainName: "example.com", SubDomains: []string{"www"}, RecordSet: settings.RecordSet{Enabled: true}}
+
+	for i := 0; i < 2; i++ {
+		if err := handler.UpdateIP(domain); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(fake.updates) != 1 {
+		t.Fatalf("unchanged addresses should not be sent again before the refresh: %+v", fake.updates)
+	}
+
+	_ = handler.getHosts().Touch("www.example.com", time.Now().Add(-48*time.Hour))
+	if err := handler.UpdateIP(domain); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.updates) != 2 {
+		t.Errorf("unchanged addresses should be sent again once the refresh is due: %+v", fake.updates)
+	}
+}
+
+func TestUpdateRecordSetsFlapping(t *testing.T) {
+	conf := &settings.Settings{Debounce: settings.Debounce{Enabled: true, MaxUpdates: 1}}
+	handler, fake, manager := newRecordSetHandler(t, conf, "203.0.113.2")
+	domain := &settings.Domain{DomainName: "example.com", SubDomains: []string{"www"}, RecordSet: settings.RecordSet{Enabled: true}}
+	handler.setCachedIP(domain, "203.0.113.1")
+	handler.recordUpdate("www.example.com", time.Now())
+
+	if err := handler.UpdateIP(domain); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.updates) != 0 {
+		t.Errorf("record set should not be updated over the limit: %+v", fake.updates)
+	}
+
+	if !slices.Equal(manager.events, []string{notification.EventFlapping}) {
+		t.Errorf("flapping should be reported, got %v", manager.events)
+	}
+
+	// the addresses are sent again on the next poll
+	if cachedIP := handler.getCachedIP(domain); cachedIP != "203.0.113.1" {
+		t.Errorf("addresses which are not published should not be cached, got %s", cachedIP)
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"log"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// refreshPeriod returns the period after which the unchanged IP is sent again, zero if it is not.
+func (handler *Handler) refreshPeriod(domain *settings.Domain) time.Duration {
+	days := domain.RefreshDays
+	if days == 0 {
+		days = handler.Configuration.RefreshDays
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// refreshDue reports whether the record of the hostname has to be sent again.
+// A hostname which update time is unknown is due.
+func (handler *Handler) refreshDue(domain *settings.Domain, hostname string, now time.Time) bool {
+	period := handler.refreshPeriod(domain)
+	return period > 0 && now.Sub(handler.getHosts().Last(hostname)) >= period
+}
+
+// domainRefreshDue reports whether any subdomain of the domain has to be sent again.
+func (handler *Handler) domainRefreshDue(domain *settings.Domain, now time.Time) bool {
+	for _, subdomainName := range handler.subDomains(domain) {
+		hostname := utils.FQDN(domain.DomainName, subdomainName)
+
+		if handler.refreshDue(domain, hostname, now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// touchRefresh records the update of the hostname if the forced refresh is enabled.
+func (handler *Handler) touchRefresh(domain *settings.Domain, hostname string, now time.Time) {
+	if handler.refreshPeriod(domain) <= 0 {
+		return
+	}
+
+	if err := handler.getHosts().Touch(hostname, now); err != nil {
+		log.Printf("Failed to save the update time of %s: %s", hostname, err)
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+func TestRefreshDue(t *testing.T) {
+	handler := &Handler{Configuration: &settings.Settings{RefreshDays: 30}}
+	domain := &settings.Domain{DomainName: "example.com", SubDomains: []string{"www"}, RefreshDays: 7}
+	now := time.Now()
+
+	if !handler.domainRefreshDue(domain, now) {
+		t.Error("hostname with unknown update time should be due")
+	}
+
+	handler.touchRefresh(domain, "www.example.com", now)
+	if handler.domainRefreshDue(domain, now.Add(6*24*time.Hour)) {
+		t.Error("refresh should not be due before the period of the domain")
+	}
+
+	if !handler.domainRefreshDue(domain, now.Add(7*24*time.Hour)) {
+		t.Error("refresh should be due after the period of the domain")
+	}
+
+	if !handler.domainRefreshDue(&settings.Domain{DomainName: "example.org", SubDomains: []string{"www"}}, now) {
+		t.Error("period of the settings should be used when the domain has none")
+	}
+
+	handler.Configuration.RefreshDays = 0
+	if handler.domainRefreshDue(&settings.Domain{DomainName: "example.org", SubDomains: []string{"www"}}, now) {
+		t.Error("refresh should not be due when it is disabled")
+	}
+}
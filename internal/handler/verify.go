@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pchchv/goddns/internal/state"
+	"github.com/pchchv/goddns/internal/utils"
+	"github.com/pchchv/goddns/pkg/notification"
+	"github.com/pchchv/goddns/pkg/safe"
+	"github.com/pchchv/goddns/pkg/webhook"
+)
+
+const (
+	defaultVerifyTimeout  = 5 * time.Minute
+	defaultVerifyInterval = 10 * time.Second
+)
+
+// verifyUpdate checks the updated record in the background,
+// in the run once mode the checks are waited for by WaitVerifications before the exit.
+func (handler *Handler) verifyUpdate(hostname, ip string) {
+	if !handler.Configuration.Verify.Enabled {
+		return
+	}
+
+	state.GetStore().Set(state.RecordStatus{Hostname: hostname, IP: ip, Status: utils.UpdatePending, UpdatedAt: time.Now()})
+	runOnce := handler.Configuration.RunOnce
+	if runOnce {
+		handler.verifications.Add(1)
+	}
+
+	safe.SafeGo(func() {
+		if runOnce {
+			defer handler.verifications.Done()
+		}
+		handler.verifyRecord(hostname, ip)
+	})
+}
+
+// WaitVerifications waits until the checks of the records updated in the run once mode are done.
+func (handler *Handler) WaitVerifications() {
+	handler.verifications.Wait()
+}
+
+// verifyRecord polls the servers until all of them return the IP or the deadline passes
+// and reports the status of the update.
+func (handler *Handler) verifyRecord(hostname, ip string) {
+	conf := handler.Configuration
+	timeout, interval := defaultVerifyTimeout, defaultVerifyInterval
+	if conf.Verify.Timeout > 0 {
+		timeout = time.Duration(conf.Verify.Timeout) * time.Second
+	}
+
+	if conf.Verify.Interval > 0 {
+		interval = time.Duration(conf.Verify.Interval) * time.Second
+	}
+
+	var served, total int
+	var err error
+	for deadline := time.Now().Add(timeout); ; time.Sleep(interval) {
+		served, total, err = utils.VerifyRecord(hostname, ip, conf.GetResolvers(), conf.IPType, conf.Authoritative)
+		if err != nil {
+			log.Printf("Failed to verify the record of %s: %s", hostname, err)
+		}
+
+		if (total > 0 && served == total) || time.Now().Add(interval).After(deadline) {
+			break
+		}
+	}
+
+	status := utils.UpdateFailed
+	if total > 0 && served == total {
+		status = utils.UpdateApplied
+	} else if served > 0 {
+		status = utils.UpdatePending
+	}
+
+	message := fmt.Sprintf("Update of %s to %s is %s, %d of %d servers return it", hostname, ip, status, served, total)
+	log.Println(message)
+
+	state.GetStore().Set(state.RecordStatus{Hostname: hostname, IP: ip, Status: status, UpdatedAt: time.Now()})
+	handler.notificationManager.SendEvent(notification.EventVerification, message)
+	if conf.Verify.Webhook.Enabled {
+		if err := webhook.GetWebhook(conf).ExecuteStatus(hostname, ip, status); err != nil {
+			log.Printf("Failed to send the status of %s to the webhook: %s", hostname, err)
+		}
+	}
+}
@@ -13,9 +13,13 @@ import (
 	"github.com/pchchv/goddns/internal/provider"
 	"github.com/pchchv/goddns/internal/server"
 	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/state"
 	"github.com/pchchv/goddns/internal/utils"
 )
 
+// defaultStateFile is the name of the state file in the directory of the configuration file.
+const defaultStateFile = "goddns_state.json"
+
 var (
 	managerOnce     sync.Once
 	managerInstance *DNSManager
@@ -65,12 +69,38 @@ func (manager *DNSManager) Run() {
 		}
 	}
 
+	if manager.config.DryRun.Enabled {
+		manager.exitWithPlan()
+	}
+
 	if manager.config.RunOnce {
+		manager.handler.WaitVerifications()
 		os.Exit(0)
 	}
 }
 
+// exitWithPlan prints the plan of the dry run and exits, the exit code is 2 if any record drifts.
+func (manager *DNSManager) exitWithPlan() {
+	entries, drift := manager.handler.Plan()
+	if err := handler.WritePlan(os.Stdout, entries, manager.config.DryRun.Format); err != nil {
+		log.Println("Failed to write the plan:", err)
+		os.Exit(1)
+	}
+
+	if drift {
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
+
+// Stop stops the manager and releases the records of the domains as configured.
 func (manager *DNSManager) Stop() {
+	manager.stop()
+	manager.handler.Release()
+}
+
+// stop stops the update loops, the file watcher and the internal HTTP server.
+func (manager *DNSManager) stop() {
 	manager.cancel()
 	// close the file watcher
 	if manager.watcher != nil {
@@ -85,7 +115,8 @@ func (manager *DNSManager) Stop() {
 
 func (manager *DNSManager) Restart() {
 	log.Println("Restarting DNS manager...")
-	manager.Stop()
+	// the records are kept while the configuration is reloaded
+	manager.stop()
 
 	// wait for the goroutines to exit
 	time.Sleep(200 * time.Millisecond)
@@ -141,6 +172,7 @@ func (manager *DNSManager) initManager() error {
 	manager.handler.SetContext(manager.ctx)
 	manager.handler.SetConfiguration(manager.config)
 	manager.handler.SetProvider(manager.provider)
+	manager.handler.SetHosts(manager.loadHosts())
 	manager.handler.Init()
 
 	// if RunOnce is true, we don't need to create a file watcher and start the internal HTTP server
@@ -211,6 +243,22 @@ func (manager *DNSManager) startMonitor() {
 	}
 }
 
+// loadHosts loads the state of the hostnames from the state file,
+// which is kept next to the configuration file if it is not set.
+func (manager *DNSManager) loadHosts() *state.Hosts {
+	path := manager.config.StateFile
+	if path == "" {
+		path = filepath.Join(filepath.Dir(manager.configPath), defaultStateFile)
+	}
+
+	hosts, err := state.LoadHosts(path)
+	if err != nil {
+		log.Printf("Failed to load the state file %s: %s", path, err)
+	}
+
+	return hosts
+}
+
 func getFileName(configPath string) string {
 	// get the file name from the path
 	// e.g. /etc/goddns/config.json -> config.json
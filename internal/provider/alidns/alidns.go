@@ -66,15 +66,17 @@ type AliDNS struct {
 	AccessKeyID     string
 	AccessKeySecret string
 	IPType          string
+	client          *http.Client
 }
 
 // NewAliDNS function creates instance of AliDNS and return.
-func NewAliDNS(key, secret, ipType string) *AliDNS {
+func NewAliDNS(key, secret, ipType string, client *http.Client) *AliDNS {
 	once.Do(func() {
 		instance = &AliDNS{
 			AccessKeyID:     key,
 			AccessKeySecret: secret,
 			IPType:          ipType,
+			client:          client,
 		}
 	})
 	return instance
@@ -95,7 +97,7 @@ func (d *AliDNS) GetDomainRecords(domain, rr string) []DomainRecord {
 	}
 
 	urlPath := d.genRequestURL(params)
-	body, err := getHTTPBody(urlPath)
+	body, err := d.getHTTPBody(urlPath)
 	if err != nil {
 		fmt.Printf("GetDomainRecords error.%+v\n", err)
 	} else {
@@ -131,7 +133,7 @@ func (d *AliDNS) UpdateDomainRecord(r DomainRecord) (err error) {
 		return errors.New("failed to generate request URL")
 	}
 
-	if _, err = getHTTPBody(urlPath); err != nil {
+	if _, err = d.getHTTPBody(urlPath); err != nil {
 		fmt.Printf("UpdateDomainRecord error.%+v\n", err)
 	}
 
@@ -174,8 +176,8 @@ func (d *AliDNS) genRequestURL(params map[string]string) string {
 	return fmt.Sprintf("%s?%s&Signature=%s", baseURL, path, url.QueryEscape(sign))
 }
 
-func getHTTPBody(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+func (d *AliDNS) getHTTPBody(url string) ([]byte, error) {
+	resp, err := d.client.Get(url)
 	if err != nil {
 		return nil, err
 	}
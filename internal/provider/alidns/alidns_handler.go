@@ -5,6 +5,7 @@ import (
 	"log"
 
 	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
 )
 
 type DNSProvider struct {
@@ -12,8 +13,8 @@ type DNSProvider struct {
 }
 
 func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	log.Printf("%s.%s - Start to update record IP...", subdomainName, domainName)
-	records := provider.aliDNS.GetDomainRecords(domainName, subdomainName)
+	log.Printf("%s - Start to update record IP...", utils.FQDN(domainName, subdomainName))
+	records := provider.aliDNS.GetDomainRecords(recordName(domainName, subdomainName))
 	if len(records) == 0 {
 		log.Fatalf("Cannot get subdomain [%s] from AliDNS.", subdomainName)
 		return fmt.Errorf("cannot get subdomain [%s] from AliDNS", subdomainName)
@@ -36,5 +37,11 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.aliDNS = NewAliDNS(
 		conf.Email,
 		conf.Password,
-		conf.IPType)
+		conf.IPType,
+		utils.GetHTTPClient(conf))
+}
+
+// recordName returns the zone and the record name of the subdomain, "@" for the apex.
+func recordName(domainName, subdomainName string) (domain, rr string) {
+	return utils.ZoneName(domainName), utils.RecordName(domainName, subdomainName, utils.RootDomain)
 }
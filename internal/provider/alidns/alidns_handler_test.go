@@ -0,0 +1,22 @@
+package alidns
+
+import "testing"
+
+func TestRecordName(t *testing.T) {
+	tests := []struct {
+		domain, subdomain string
+		zone, rr          string
+	}{
+		{"example.com", "@", "example.com", "@"},
+		{"Example.com", "WWW", "example.com", "www"},
+		{"example.com", "*", "example.com", "*"},
+		{"example.com", "a.b", "example.com", "a.b"},
+		{"bücher.example", "www", "xn--bcher-kva.example", "www"},
+	}
+
+	for _, tt := range tests {
+		if zone, rr := recordName(tt.domain, tt.subdomain); zone != tt.zone || rr != tt.rr {
+			t.Errorf("recordName(%q, %q) = %q, %q, want %q, %q", tt.domain, tt.subdomain, zone, rr, tt.zone, tt.rr)
+		}
+	}
+}
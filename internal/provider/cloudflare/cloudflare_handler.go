@@ -3,10 +3,13 @@ package cloudflare
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/pchchv/goddns/internal/settings"
@@ -30,13 +33,15 @@ type ZoneResponse struct {
 
 // DNSRecord for Cloudflare API.
 type DNSRecord struct {
-	ID      string `json:"id"`
-	IP      string `json:"content"`
-	Name    string `json:"name"`
-	Proxied bool   `json:"proxied"`
-	Type    string `json:"type"`
-	ZoneID  string `json:"zone_id"`
-	TTL     int32  `json:"ttl"`
+	ID      string   `json:"id"`
+	IP      string   `json:"content"`
+	Name    string   `json:"name"`
+	Proxied bool     `json:"proxied"`
+	Type    string   `json:"type"`
+	ZoneID  string   `json:"zone_id"`
+	TTL     int32    `json:"ttl"`
+	Comment string   `json:"comment,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
 }
 
 // SetIP updates DNSRecord.IP.
@@ -44,6 +49,11 @@ func (r *DNSRecord) SetIP(ip string) {
 	r.IP = ip
 }
 
+// hasMarker reports whether the comment or a tag of the record carries the marker.
+func (r *DNSRecord) hasMarker(marker string) bool {
+	return strings.Contains(r.Comment, marker) || slices.Contains(r.Tags, marker)
+}
+
 type DNSRecordUpdateResponse struct {
 	Record  DNSRecord `json:"result"`
 	Success bool      `json:"success"`
@@ -67,19 +77,23 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 
 func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
 	log.Printf("Checking IP for domain %s", domainName)
-	zoneID := provider.getZone(domainName)
+	zoneID := provider.getZone(utils.ZoneName(domainName))
 	if zoneID != "" {
-		records := provider.getDNSRecords(zoneID)
+		records, err := provider.getDNSRecords(zoneID)
+		if err != nil {
+			return err
+		}
+
 		matched := false
 		// update records
 		for _, rec := range records {
 			rec := rec
-			if !recordTracked(provider.getCurrentDomain(domainName), &rec) {
+			if !provider.isTracked(domainName, &rec) {
 				log.Println("Skipping record:", rec.Name)
 				continue
 			}
 
-			if strings.Contains(rec.Name, subdomainName) || rec.Name == domainName {
+			if rec.Name == utils.FQDN(domainName, subdomainName) {
 				if rec.IP != ip {
 					log.Printf("IP mismatch: Current(%+v) vs Cloudflare(%+v)", ip, rec.IP)
 					provider.updateRecord(rec, ip)
@@ -156,20 +170,21 @@ func (provider *DNSProvider) getZone(domain string) string {
 	return ""
 }
 
-func (provider *DNSProvider) getCurrentDomain(domainName string) *settings.Domain {
-	for _, domain := range provider.configuration.Domains {
-		domain := domain
-		if domain.DomainName == domainName {
-			return &domain
+// isTracked reports whether the record is tracked by any entry of the domain,
+// the same domain is listed once per WAN when its subdomains are mapped to several WANs.
+func (provider *DNSProvider) isTracked(domainName string, record *DNSRecord) bool {
+	for i := range provider.configuration.Domains {
+		domain := &provider.configuration.Domains[i]
+		if utils.ZoneName(domain.DomainName) == utils.ZoneName(domainName) && recordTracked(domain, record) {
+			return true
 		}
 	}
 
-	return nil
+	return false
 }
 
 // getDNSRecords gets all DNS A records for a zone.
-func (provider *DNSProvider) getDNSRecords(zoneID string) []DNSRecord {
-	var empty []DNSRecord
+func (provider *DNSProvider) getDNSRecords(zoneID string) ([]DNSRecord, error) {
 	var recordType string
 	var r DNSRecordResponse
 	if provider.configuration.IPType == "" || strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
@@ -182,23 +197,22 @@ func (provider *DNSProvider) getDNSRecords(zoneID string) []DNSRecord {
 	req, client := provider.newRequest("GET", fmt.Sprintf("/zones/"+zoneID+"/dns_records?type=%s&page=1&per_page=500", recordType), nil)
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Fatal("Request error:", err)
-		return empty
+		return nil, fmt.Errorf("failed to query the records of zone %s: %w", zoneID, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
 	}
 
-	body, _ := io.ReadAll(resp.Body)
 	if err = json.Unmarshal(body, &r); err != nil {
-		log.Printf("Decoder error: %+v", err)
-		log.Printf("Response body: %+v", string(body))
-		return empty
+		return nil, fmt.Errorf("failed to decode the records of zone %s: %w, body: %s", zoneID, err, string(body))
 	} else if !r.Success {
-		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Response failed: %+v", string(body))
-		return empty
-
+		return nil, fmt.Errorf("failed to query the records of zone %s: %s", zoneID, string(body))
 	}
 
-	return r.Records
+	return r.Records, nil
 }
 
 func (provider *DNSProvider) createRecord(zoneID, domain, subDomain, ip string) error {
@@ -219,11 +233,7 @@ func (provider *DNSProvider) createRecord(zoneID, domain, subDomain, ip string)
 		newRecord.Proxied = true
 	}
 
-	if subDomain == utils.RootDomain {
-		newRecord.Name = utils.RootDomain
-	} else {
-		newRecord.Name = fmt.Sprintf("%s.%s", subDomain, domain)
-	}
+	newRecord.Name = utils.FQDN(domain, subDomain)
 
 	content, err := json.Marshal(newRecord)
 	if err != nil {
@@ -294,16 +304,254 @@ func (provider *DNSProvider) updateRecord(record DNSRecord, newIP string) string
 	return lastIP
 }
 
-// recordTracked checks if record is present in domain conf.
+// recordTracked checks if record is present in domain conf, as a subdomain or a LAN host,
+// or is discovered by its marker.
 func recordTracked(domain *settings.Domain, record *DNSRecord) bool {
 	for _, subDomain := range domain.SubDomains {
-		sd := fmt.Sprintf("%s.%s", subDomain, domain.DomainName)
-		if record.Name == sd {
+		if record.Name == utils.FQDN(domain.DomainName, subDomain) {
 			return true
-		} else if subDomain == utils.RootDomain && record.Name == domain.DomainName {
+		}
+	}
+
+	for _, host := range domain.LANHosts {
+		if record.Name == utils.FQDN(domain.DomainName, host.SubDomain) {
 			return true
 		}
 	}
 
-	return false
+	return domain.Discovery.Enabled && record.hasMarker(domain.Discovery.GetMarker())
+}
+
+// UpdateRecordSet publishes the addresses as the records of the hostname,
+// the records are created and deleted to match the set.
+func (provider *DNSProvider) UpdateRecordSet(domainName, subdomainName string, update utils.RecordSetUpdate) error {
+	zoneID := provider.getZone(utils.ZoneName(domainName))
+	if zoneID == "" {
+		return fmt.Errorf("failed to find zone for domain: %s", domainName)
+	}
+
+	name := utils.FQDN(domainName, subdomainName)
+
+	records, err := provider.getDNSRecords(zoneID)
+	if err != nil {
+		return err
+	}
+
+	var existing []string
+	recordIDs := map[string]string{}
+	for _, rec := range records {
+		if rec.Name == name {
+			existing = append(existing, rec.IP)
+			recordIDs[rec.IP] = rec.ID
+		}
+	}
+
+	add, remove := update.Diff(existing)
+	for _, ip := range add {
+		if err := provider.createRecord(zoneID, domainName, subdomainName, ip); err != nil {
+			return err
+		}
+		log.Printf("Record [%s] created with IP address: %s", name, ip)
+	}
+
+	for _, ip := range remove {
+		if err := provider.deleteRecord(zoneID, recordIDs[ip]); err != nil {
+			return err
+		}
+		log.Printf("Record [%s] with IP address %s deleted", name, ip)
+	}
+
+	return nil
+}
+
+// DeleteRecords deletes the address records of the hostname.
+func (provider *DNSProvider) DeleteRecords(domainName, subdomainName string) error {
+	return provider.UpdateRecordSet(domainName, subdomainName, utils.RecordSetUpdate{Mode: utils.RecordSetReplace})
+}
+
+// deleteRecord deletes the DNS record by its ID.
+func (provider *DNSProvider) deleteRecord(zoneID, recordID string) error {
+	req, client := provider.newRequest("DELETE", "/zones/"+zoneID+"/dns_records/"+recordID, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var r struct {
+		Success bool `json:"success"`
+	}
+	if err = json.Unmarshal(body, &r); err != nil {
+		return err
+	} else if !r.Success {
+		return fmt.Errorf("failed to delete record: %+v", string(body))
+	}
+
+	return nil
+}
+
+// Record is the DNS record of any type, the HTTPS and SVCB records are set with the data.
+type Record struct {
+	ID      string      `json:"id,omitempty"`
+	Type    string      `json:"type"`
+	Name    string      `json:"name"`
+	Content string      `json:"content,omitempty"`
+	Data    *RecordData `json:"data,omitempty"`
+	TTL     int         `json:"ttl"`
+}
+
+// RecordData is the data of the HTTPS and SVCB records.
+type RecordData struct {
+	Priority int    `json:"priority"`
+	Target   string `json:"target"`
+	Value    string `json:"value"`
+}
+
+// UpsertRecord creates the record of the type or updates its value.
+// The value of the HTTPS and SVCB records is the priority, the target and the parameters.
+func (provider *DNSProvider) UpsertRecord(domainName, subdomainName, recordType, value string, ttl int) error {
+	zoneID := provider.getZone(utils.ZoneName(domainName))
+	if zoneID == "" {
+		return fmt.Errorf("failed to find zone for domain: %s", domainName)
+	}
+
+	record := Record{Type: recordType, Name: utils.FQDN(domainName, subdomainName), TTL: ttl}
+
+	if record.TTL == 0 {
+		record.TTL = 1
+	}
+
+	if recordType == utils.RecordTypeHTTPS || recordType == utils.RecordTypeSVCB {
+		data, err := parseServiceData(value)
+		if err != nil {
+			return err
+		}
+		record.Data = data
+	} else {
+		record.Content = value
+	}
+
+	var r struct {
+		Records []Record `json:"result"`
+		Success bool     `json:"success"`
+	}
+
+	req, client := provider.newRequest("GET", "/zones/"+zoneID+"/dns_records?type="+recordType+"&name="+record.Name, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err = json.Unmarshal(body, &r); err != nil {
+		return err
+	} else if !r.Success {
+		return fmt.Errorf("failed to get records: %+v", string(body))
+	}
+
+	method, url := "POST", "/zones/"+zoneID+"/dns_records"
+	if len(r.Records) > 0 {
+		existing := r.Records[0]
+		if record.Data == nil && existing.Content == record.Content || record.Data != nil && existing.Data != nil && *existing.Data == *record.Data {
+			log.Printf("Record OK: %s %s", record.Name, recordType)
+			return nil
+		}
+		method, url = "PUT", url+"/"+existing.ID
+	}
+
+	content, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	req, client = provider.newRequest(method, url, bytes.NewBuffer(content))
+	if resp, err = client.Do(req); err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	if body, err = io.ReadAll(resp.Body); err != nil {
+		return err
+	}
+
+	var updated DNSRecordUpdateResponse
+	if err = json.Unmarshal(body, &updated); err != nil {
+		return err
+	} else if !updated.Success {
+		return fmt.Errorf("failed to publish record: %+v", string(body))
+	}
+
+	log.Printf("Record [%s] %s published: %s", record.Name, recordType, value)
+	return nil
+}
+
+// parseServiceData parses the value of the HTTPS or SVCB record, e.g. "1 . alpn=h2 ipv4hint=192.0.2.1".
+func parseServiceData(value string) (*RecordData, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 {
+		return nil, errors.New("service record should have the priority and the target: " + value)
+	}
+
+	priority, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid priority of service record: %w", err)
+	}
+
+	return &RecordData{Priority: priority, Target: fields[1], Value: strings.Join(fields[2:], " ")}, nil
+}
+
+// GetRecords returns the addresses of the hostname.
+func (provider *DNSProvider) GetRecords(domainName, subdomainName string) ([]string, error) {
+	zoneID := provider.getZone(utils.ZoneName(domainName))
+	if zoneID == "" {
+		return nil, fmt.Errorf("failed to find zone for domain: %s", domainName)
+	}
+
+	name := utils.FQDN(domainName, subdomainName)
+
+	records, err := provider.getDNSRecords(zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []string
+	for _, rec := range records {
+		if rec.Name == name {
+			ips = append(ips, rec.IP)
+		}
+	}
+
+	return ips, nil
+}
+
+// DiscoverRecords returns the subdomains which address records carry the marker in their comment or tags.
+func (provider *DNSProvider) DiscoverRecords(domainName, marker string) ([]string, error) {
+	zoneID := provider.getZone(utils.ZoneName(domainName))
+	if zoneID == "" {
+		return nil, fmt.Errorf("failed to find zone for domain: %s", domainName)
+	}
+
+	records, err := provider.getDNSRecords(zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	var subdomains []string
+	for _, rec := range records {
+		if !rec.hasMarker(marker) {
+			continue
+		}
+
+		if subdomain, ok := utils.SubdomainOf(domainName, rec.Name); ok && !slices.Contains(subdomains, subdomain) {
+			subdomains = append(subdomains, subdomain)
+		}
+	}
+
+	return subdomains, nil
 }
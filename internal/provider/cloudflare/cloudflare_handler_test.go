@@ -2,10 +2,17 @@ package cloudflare
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
 )
 
 func TestResponseToJSON(t *testing.T) {
@@ -171,3 +178,205 @@ func TestRecordTracked(t *testing.T) {
 		}
 	}
 }
+
+// fakeAPI serves the zone example.com with the records.
+type fakeAPI struct {
+	mu      sync.Mutex
+	records []DNSRecord
+	created int
+	failing bool
+}
+
+func (api *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	switch {
+	case r.URL.Path == "/zones":
+		json.NewEncoder(w).Encode(ZoneResponse{Zones: []Zone{{ID: "z1", Name: "example.com"}}, Success: true})
+	case r.Method == http.MethodGet && api.failing:
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"success": false, "errors": [{"code": 10000, "message": "internal error"}]}`))
+	case r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(DNSRecordResponse{Records: api.records, Success: true})
+	case r.Method == http.MethodPost:
+		var rec DNSRecord
+		json.NewDecoder(r.Body).Decode(&rec)
+		api.created++
+		rec.ID = fmt.Sprintf("new-%d", api.created)
+		api.records = append(api.records, rec)
+		json.NewEncoder(w).Encode(DNSRecordUpdateResponse{Record: rec, Success: true})
+	case r.Method == http.MethodPut:
+		var rec DNSRecord
+		json.NewDecoder(r.Body).Decode(&rec)
+		for i := range api.records {
+			if api.records[i].ID == id {
+				api.records[i] = rec
+			}
+		}
+		json.NewEncoder(w).Encode(DNSRecordUpdateResponse{Record: rec, Success: true})
+	case r.Method == http.MethodDelete:
+		api.records = slices.DeleteFunc(api.records, func(rec DNSRecord) bool { return rec.ID == id })
+		w.Write([]byte(`{"success": true}`))
+	}
+}
+
+func (api *fakeAPI) ips(name string) []string {
+	var ips []string
+	for _, rec := range api.records {
+		if rec.Name == name {
+			ips = append(ips, rec.IP)
+		}
+	}
+
+	sort.Strings(ips)
+	return ips
+}
+
+func newFakeProvider(t *testing.T, conf *settings.Settings, records ...DNSRecord) (*DNSProvider, *fakeAPI) {
+	api := &fakeAPI{records: records}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	provider := &DNSProvider{}
+	provider.Init(conf)
+	provider.API = server.URL
+	return provider, api
+}
+
+func TestUpdateIPRecordNames(t *testing.T) {
+	conf := &settings.Settings{Domains: []settings.Domain{{DomainName: "example.com", SubDomains: []string{"@", "www", "*", "a.b"}}}}
+	provider, api := newFakeProvider(t, conf,
+		DNSRecord{ID: "1", IP: "198.51.100.1", Name: "www.example.com", Type: "A", ZoneID: "z1"},
+		DNSRecord{ID: "2", IP: "198.51.100.1", Name: "wwwx.example.com", Type: "A", ZoneID: "z1"},
+	)
+
+	for _, subdomain := range conf.Domains[0].SubDomains {
+		if err := provider.UpdateIP("example.com", subdomain, "203.0.113.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"example.com", "www.example.com", "*.example.com", "a.b.example.com"} {
+		if ips := api.ips(name); !slices.Equal(ips, []string{"203.0.113.1"}) {
+			t.Errorf("records of %s = %v", name, ips)
+		}
+	}
+
+	if ips := api.ips("wwwx.example.com"); !slices.Equal(ips, []string{"198.51.100.1"}) {
+		t.Errorf("record of the other host should not be updated: %v", ips)
+	}
+}
+
+func TestUpdateRecordSet(t *testing.T) {
+	provider, api := newFakeProvider(t, &settings.Settings{},
+		DNSRecord{ID: "1", IP: "203.0.113.1", Name: "www.example.com", Type: "A", ZoneID: "z1"},
+		DNSRecord{ID: "2", IP: "198.51.100.2", Name: "www.example.com", Type: "A", ZoneID: "z1"},
+		DNSRecord{ID: "3", IP: "203.0.113.1", Name: "mail.example.com", Type: "A", ZoneID: "z1"},
+	)
+
+	update := utils.RecordSetUpdate{IPs: []string{"203.0.113.5"}, Previous: []string{"203.0.113.1"}, Mode: utils.RecordSetMerge}
+	if err := provider.UpdateRecordSet("example.com", "www", update); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"198.51.100.2", "203.0.113.5"}; !slices.Equal(api.ips("www.example.com"), want) {
+		t.Errorf("record set of www.example.com = %v, want %v", api.ips("www.example.com"), want)
+	}
+
+	if len(api.records) != 3 {
+		t.Errorf("records of the other hosts should be kept: %+v", api.records)
+	}
+}
+
+func TestUpdateRecordSetAPIError(t *testing.T) {
+	provider, api := newFakeProvider(t, &settings.Settings{},
+		DNSRecord{ID: "1", IP: "203.0.113.1", Name: "www.example.com", Type: "A", ZoneID: "z1"},
+	)
+	api.failing = true
+
+	update := utils.RecordSetUpdate{IPs: []string{"203.0.113.1"}, Mode: utils.RecordSetReplace}
+	if err := provider.UpdateRecordSet("example.com", "www", update); err == nil {
+		t.Error("the error of the API should be returned")
+	}
+
+	if err := provider.DeleteRecords("example.com", "www"); err == nil {
+		t.Error("the error of the API should be returned on delete")
+	}
+
+	if _, err := provider.GetRecords("example.com", "www"); err == nil {
+		t.Error("the error of the API should be returned on read")
+	}
+
+	if api.created != 0 || len(api.records) != 1 {
+		t.Errorf("records should be kept on the API error: %+v", api.records)
+	}
+}
+
+func TestDiscoverRecords(t *testing.T) {
+	conf := &settings.Settings{Domains: []settings.Domain{{DomainName: "example.com", Discovery: settings.Discovery{Enabled: true}}}}
+	provider, api := newFakeProvider(t, conf,
+		DNSRecord{ID: "1", IP: "198.51.100.1", Name: "www.example.com", Type: "A", ZoneID: "z1", Tags: []string{"goddns:managed"}},
+		DNSRecord{ID: "2", IP: "198.51.100.1", Name: "example.com", Type: "A", ZoneID: "z1", Comment: "goddns:managed by ops"},
+		DNSRecord{ID: "3", IP: "198.51.100.1", Name: "mail.example.com", Type: "A", ZoneID: "z1"},
+	)
+
+	subdomains, err := provider.DiscoverRecords("example.com", conf.Domains[0].Discovery.GetMarker())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(subdomains, []string{"www", "@"}) {
+		t.Errorf("discovered subdomains = %v", subdomains)
+	}
+
+	if err = provider.UpdateIP("example.com", "www", "203.0.113.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ips := api.ips("www.example.com"); !slices.Equal(ips, []string{"203.0.113.1"}) {
+		t.Errorf("discovered record should be updated in place: %v", ips)
+	}
+}
+
+func TestUpdateIPDomainPerWAN(t *testing.T) {
+	conf := &settings.Settings{Domains: []settings.Domain{
+		{DomainName: "example.com", SubDomains: []string{"www"}, WAN: "wan1"},
+		{DomainName: "example.com", SubDomains: []string{"vpn"}, WAN: "wan2"},
+	}}
+	provider, api := newFakeProvider(t, conf,
+		DNSRecord{ID: "1", IP: "198.51.100.1", Name: "www.example.com", Type: "A", ZoneID: "z1"},
+		DNSRecord{ID: "2", IP: "198.51.100.2", Name: "vpn.example.com", Type: "A", ZoneID: "z1"},
+	)
+
+	for i := 0; i < 2; i++ {
+		if err := provider.UpdateIP("example.com", "vpn", "203.0.113.2"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if ips := api.ips("vpn.example.com"); !slices.Equal(ips, []string{"203.0.113.2"}) || api.created != 0 {
+		t.Errorf("record of the second entry should be updated in place: %v, %d created", ips, api.created)
+	}
+}
+
+func TestUpdateIPLANHost(t *testing.T) {
+	conf := &settings.Settings{IPType: "IPv6", Domains: []settings.Domain{{
+		DomainName: "example.com",
+		SubDomains: []string{"www"},
+		LANHosts:   []settings.LANHost{{SubDomain: "nas", Suffix: "::10"}},
+	}}}
+	provider, api := newFakeProvider(t, conf)
+
+	// the prefix changes twice
+	for _, ip := range []string{"2001:db8:1::10", "2001:db8:2::10", "2001:db8:3::10"} {
+		if err := provider.UpdateIP("example.com", "nas", ip); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if ips := api.ips("nas.example.com"); !slices.Equal(ips, []string{"2001:db8:3::10"}) {
+		t.Errorf("LAN host should have exactly one record: %v", ips)
+	}
+}
@@ -47,17 +47,18 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 
 func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
 	log.Printf("Checking IP for domain %s", domainName)
+	name := utils.RecordName(domainName, subdomainName, utils.RootDomain)
 	records := provider.getDNSRecords(domainName)
 	matched := false
 	// update records
 	for _, rec := range records {
 		rec := rec
-		if !recordTracked(provider.getCurrentDomain(domainName), &rec) {
+		if !provider.isTracked(domainName, &rec) {
 			log.Print("Skipping record:", rec.Name)
 			continue
 		}
 
-		if strings.Contains(rec.Name, subdomainName) || rec.Name == domainName {
+		if rec.Name == name {
 			if rec.IP != ip {
 				log.Printf("IP mismatch: Current(%+v) vs DigitalOcean(%+v)", ip, rec.IP)
 				provider.updateRecord(domainName, rec, ip)
@@ -120,7 +121,7 @@ func (provider *DNSProvider) getDNSRecords(domainName string) []DNSRecord {
 	recordType := provider.getRecordType()
 
 	log.Printf("Querying records with type: %s", recordType)
-	req, client := provider.newRequest("GET", fmt.Sprintf("/domains/"+domainName+"/records?type=%s&page=1&per_page=200", recordType), nil)
+	req, client := provider.newRequest("GET", fmt.Sprintf("/domains/%s/records?type=%s&page=1&per_page=200", utils.ZoneName(domainName), recordType), nil)
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Fatal("Request error:", err)
@@ -137,15 +138,17 @@ func (provider *DNSProvider) getDNSRecords(domainName string) []DNSRecord {
 	return r.Records
 }
 
-func (provider *DNSProvider) getCurrentDomain(domainName string) *settings.Domain {
-	for _, domain := range provider.configuration.Domains {
-		domain := domain
-		if domain.DomainName == domainName {
-			return &domain
+// isTracked reports whether the record is tracked by any entry of the domain,
+// the same domain is listed once per WAN when its subdomains are mapped to several WANs.
+func (provider *DNSProvider) isTracked(domainName string, record *DNSRecord) bool {
+	for i := range provider.configuration.Domains {
+		domain := &provider.configuration.Domains[i]
+		if utils.ZoneName(domain.DomainName) == utils.ZoneName(domainName) && recordTracked(domain, record) {
+			return true
 		}
 	}
 
-	return nil
+	return false
 }
 
 // updateRecord updates DNS Record with new IP.
@@ -153,7 +156,7 @@ func (provider *DNSProvider) updateRecord(domainName string, record DNSRecord, n
 	record.SetIP(newIP)
 	j, _ := json.Marshal(record)
 	req, client := provider.newRequest("PUT",
-		fmt.Sprintf("/domains/%s/records/%d", domainName, record.ID),
+		fmt.Sprintf("/domains/%s/records/%d", utils.ZoneName(domainName), record.ID),
 		bytes.NewBuffer(j),
 	)
 
@@ -180,23 +183,18 @@ func (provider *DNSProvider) createRecord(domain, subDomain, ip string) error {
 	recordType := provider.getRecordType()
 	newRecord := DNSRecord{
 		Type: recordType,
+		Name: utils.RecordName(domain, subDomain, utils.RootDomain),
 		IP:   ip,
 		TTL:  int32(provider.configuration.Interval),
 	}
 
-	if subDomain == utils.RootDomain {
-		newRecord.Name = utils.RootDomain
-	} else {
-		newRecord.Name = subDomain
-	}
-
 	content, err := json.Marshal(newRecord)
 	if err != nil {
 		log.Fatalf("Encoder error: %+v", err)
 		return err
 	}
 
-	req, client := provider.newRequest("POST", fmt.Sprintf("/domains/%s/records", domain), bytes.NewBuffer(content))
+	req, client := provider.newRequest("POST", fmt.Sprintf("/domains/%s/records", utils.ZoneName(domain)), bytes.NewBuffer(content))
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Fatal("Request error:", err)
@@ -220,10 +218,16 @@ func (provider *DNSProvider) createRecord(domain, subDomain, ip string) error {
 	return nil
 }
 
-// recordTracked checks if record is present in domain conf.
+// recordTracked checks if record is present in domain conf, as a subdomain or a LAN host.
 func recordTracked(domain *settings.Domain, record *DNSRecord) bool {
 	for _, subDomain := range domain.SubDomains {
-		if record.Name == subDomain {
+		if record.Name == utils.RecordName(domain.DomainName, subDomain, utils.RootDomain) {
+			return true
+		}
+	}
+
+	for _, host := range domain.LANHosts {
+		if record.Name == utils.RecordName(domain.DomainName, host.SubDomain, utils.RootDomain) {
 			return true
 		}
 	}
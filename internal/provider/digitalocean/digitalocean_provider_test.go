@@ -2,7 +2,11 @@ package digitalocean
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/pchchv/goddns/internal/settings"
@@ -142,3 +146,108 @@ func TestRecordTracked(t *testing.T) {
 		t.Errorf("Unexpected amount of domains matched: %#v != 2", matchedDomains)
 	}
 }
+
+func TestUpdateIPRecordNames(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	records := []DNSRecord{
+		{ID: 1, Type: "A", Name: "www", IP: "198.51.100.1"},
+		{ID: 2, Type: "A", Name: "wwwx", IP: "198.51.100.1"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(DomainRecordsResponse{Records: records})
+		case http.MethodPut:
+			var rec DNSRecord
+			json.NewDecoder(r.Body).Decode(&rec)
+			for i := range records {
+				if records[i].ID == rec.ID {
+					records[i] = rec
+				}
+			}
+			json.NewEncoder(w).Encode(rec)
+		case http.MethodPost:
+			var rec DNSRecord
+			json.NewDecoder(r.Body).Decode(&rec)
+			rec.ID = int32(len(records) + 1)
+			records = append(records, rec)
+			json.NewEncoder(w).Encode(rec)
+		}
+	}))
+	defer server.Close()
+
+	conf := &settings.Settings{Domains: []settings.Domain{{DomainName: "Example.com", SubDomains: []string{"@", "WWW", "*", "a.b"}}}}
+	provider := &DNSProvider{}
+	provider.Init(conf)
+	provider.API = server.URL
+	for _, subdomain := range conf.Domains[0].SubDomains {
+		if err := provider.UpdateIP("Example.com", subdomain, "203.0.113.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ips := map[string]string{}
+	for _, rec := range records {
+		ips[rec.Name] = rec.IP
+	}
+
+	expected := map[string]string{"@": "203.0.113.1", "www": "203.0.113.1", "*": "203.0.113.1", "a.b": "203.0.113.1", "wwwx": "198.51.100.1"}
+	for name, ip := range expected {
+		if ips[name] != ip {
+			t.Errorf("record %s = %q, want %q", name, ips[name], ip)
+		}
+	}
+
+	if !slices.Contains(paths, "POST /domains/example.com/records") {
+		t.Errorf("records should be created in the zone example.com: %v", paths)
+	}
+}
+
+func TestUpdateIPDomainPerWAN(t *testing.T) {
+	var created int
+	records := []DNSRecord{
+		{ID: 1, Type: "A", Name: "www", IP: "198.51.100.1"},
+		{ID: 2, Type: "A", Name: "vpn", IP: "198.51.100.2"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(DomainRecordsResponse{Records: records})
+		case http.MethodPut:
+			var rec DNSRecord
+			json.NewDecoder(r.Body).Decode(&rec)
+			for i := range records {
+				if records[i].ID == rec.ID {
+					records[i] = rec
+				}
+			}
+			json.NewEncoder(w).Encode(rec)
+		case http.MethodPost:
+			created++
+			json.NewEncoder(w).Encode(DNSRecord{})
+		}
+	}))
+	defer server.Close()
+
+	conf := &settings.Settings{Domains: []settings.Domain{
+		{DomainName: "example.com", SubDomains: []string{"www"}, WAN: "wan1"},
+		{DomainName: "example.com", SubDomains: []string{"vpn"}, WAN: "wan2"},
+	}}
+	provider := &DNSProvider{}
+	provider.Init(conf)
+	provider.API = server.URL
+	for i := 0; i < 2; i++ {
+		if err := provider.UpdateIP("example.com", "vpn", "203.0.113.2"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if records[1].IP != "203.0.113.2" || created != 0 {
+		t.Errorf("record of the second entry should be updated in place: %+v, %d created", records[1], created)
+	}
+}
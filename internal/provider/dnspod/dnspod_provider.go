@@ -20,25 +20,29 @@ const providerURL = "https://dnsapi.cn"
 
 type DNSProvider struct {
 	configuration *settings.Settings
+	API           string
 }
 
 func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
+	provider.API = providerURL
 }
 
 func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	domainID := provider.getDomain(domainName)
+	domainID := provider.getDomain(utils.ZoneName(domainName))
 	if domainID == -1 {
 		return errors.New("domain ID not found")
 	}
 
-	subdomainID, currentIP := provider.getSubDomain(domainID, subdomainName)
+	hostname := utils.FQDN(domainName, subdomainName)
+	name := utils.RecordName(domainName, subdomainName, utils.RootDomain)
+	subdomainID, currentIP := provider.getSubDomain(domainID, name)
 	if subdomainID == "" || currentIP == "" {
-		return fmt.Errorf("domain or subdomain not configured yet. domain: %s.%s subDomainID: %s ip: %s", subdomainName, domainName, subdomainID, ip)
+		return fmt.Errorf("domain or subdomain not configured yet. domain: %s subDomainID: %s ip: %s", hostname, subdomainID, ip)
 	}
 
-	log.Printf("%s.%s Start to update record IP...", subdomainName, domainName)
-	return provider.updateIP(domainID, subdomainID, subdomainName, ip)
+	log.Printf("%s Start to update record IP...", hostname)
+	return provider.updateIP(domainID, subdomainID, name, ip)
 }
 
 // generateHeader generates the request header for DNSPod API.
@@ -68,7 +72,7 @@ func (provider *DNSProvider) postData(url string, content url.Values) (string, e
 	}
 
 	values := provider.generateHeader(content)
-	req, _ := http.NewRequest("POST", providerURL+url, strings.NewReader(values.Encode()))
+	req, _ := http.NewRequest("POST", provider.API+url, strings.NewReader(values.Encode()))
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("User-Agent", fmt.Sprintf("GoDNS/0.1 (%s)", ""))
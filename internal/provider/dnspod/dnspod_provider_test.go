@@ -0,0 +1,43 @@
+package dnspod
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+func TestUpdateIPRecordNames(t *testing.T) {
+	var modified []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/Domain.List":
+			fmt.Fprint(w, `{"status": {"code": "1"}, "domains": [{"id": 7, "name": "xn--bcher-kva.example"}]}`)
+		case "/Record.List":
+			fmt.Fprintf(w, `{"status": {"code": "1"}, "records": [{"id": "1", "name": %q, "value": "198.51.100.1"}]}`, r.Form.Get("sub_domain"))
+		case "/Record.Modify":
+			modified = append(modified, r.Form.Get("sub_domain"))
+			fmt.Fprint(w, `{"status": {"code": "1"}}`)
+		}
+	}))
+	defer server.Close()
+
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{IPType: "IPv4"})
+	provider.API = server.URL
+
+	subdomains := []string{"@", "WWW", "*", "a.b"}
+	expected := []string{"@", "www", "*", "a.b"}
+	for _, subdomain := range subdomains {
+		if err := provider.UpdateIP("Bücher.example", subdomain, "203.0.113.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fmt.Sprint(modified) != fmt.Sprint(expected) {
+		t.Errorf("modified records %v, want %v", modified, expected)
+	}
+}
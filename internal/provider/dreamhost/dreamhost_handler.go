@@ -25,8 +25,8 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 }
 
 func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	hostname := subdomainName + "." + domainName
-	lastIP, err := utils.ResolveDNS(hostname, provider.configuration.Resolver, provider.configuration.IPType)
+	hostname := utils.FQDN(domainName, subdomainName)
+	lastIP, err := utils.ResolveDNS(hostname, provider.configuration.GetResolvers(), provider.configuration.IPType)
 	if err != nil {
 		log.Println(err)
 		return err
@@ -37,32 +37,9 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 
 // updateDNS can add or remove DNS records.
 func (provider *DNSProvider) updateDNS(dns, ip, hostname, action string) error {
-	var ipType string
-	if provider.configuration.IPType == "" || strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
-		ipType = utils.IPTypeA
-	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
-		ipType = utils.IPTypeAAAA
-	}
-
-	// Generates UUID
-	uid, _ := uuid.NewRandom()
-	values := url.Values{}
-	values.Add("record", hostname)
-	values.Add("key", provider.configuration.LoginToken)
-	values.Add("type", ipType)
-	values.Add("unique_id", uid.String())
-	switch action {
-	case "remove":
-		// Build URL query (remove)
-		values.Add("cmd", "dns-remove_record")
-		values.Add("value", dns)
-	case "add":
-		// Build URL query (add)
-		values.Add("cmd", "dns-add_record")
-		values.Add("value", ip)
-	default:
-		log.Fatalf("Unknown action: %s", action)
-		return fmt.Errorf("unknown action: %s", action)
+	values, err := provider.requestValues(dns, ip, hostname, action)
+	if err != nil {
+		return err
 	}
 
 	client := utils.GetHTTPClient(provider.configuration)
@@ -103,3 +80,36 @@ func (provider *DNSProvider) updateIP(hostname, currentIP, lastIP string) error
 
 	return provider.updateDNS(lastIP, currentIP, hostname, "add")
 }
+
+// requestValues returns the form of the request adding or removing the record of the hostname.
+func (provider *DNSProvider) requestValues(dns, ip, hostname, action string) (url.Values, error) {
+	var ipType string
+	if provider.configuration.IPType == "" || strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
+		ipType = utils.IPTypeA
+	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
+		ipType = utils.IPTypeAAAA
+	}
+
+	// Generates UUID
+	uid, _ := uuid.NewRandom()
+	values := url.Values{}
+	values.Add("record", hostname)
+	values.Add("key", provider.configuration.LoginToken)
+	values.Add("type", ipType)
+	values.Add("unique_id", uid.String())
+	switch action {
+	case "remove":
+		// Build URL query (remove)
+		values.Add("cmd", "dns-remove_record")
+		values.Add("value", dns)
+	case "add":
+		// Build URL query (add)
+		values.Add("cmd", "dns-add_record")
+		values.Add("value", ip)
+	default:
+		log.Fatalf("Unknown action: %s", action)
+		return nil, fmt.Errorf("unknown action: %s", action)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,32 @@
+package dreamhost
+
+import (
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+func TestRequestValues(t *testing.T) {
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{LoginToken: "key"})
+
+	tests := []struct {
+		domain, subdomain, expected string
+	}{
+		{"example.com", "@", "example.com"},
+		{"example.com", "www", "www.example.com"},
+		{"example.com", "a.b", "a.b.example.com"},
+	}
+
+	for _, tt := range tests {
+		values, err := provider.requestValues("198.51.100.1", "203.0.113.1", utils.FQDN(tt.domain, tt.subdomain), "add")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if values.Get("record") != tt.expected || values.Get("cmd") != "dns-add_record" || values.Get("value") != "203.0.113.1" {
+			t.Errorf("unexpected form of (%q, %q): %s", tt.domain, tt.subdomain, values.Encode())
+		}
+	}
+}
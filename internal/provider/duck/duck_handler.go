@@ -10,7 +10,10 @@ import (
 	"github.com/pchchv/goddns/internal/utils"
 )
 
-const URL = "https://www.duckdns.org/update?domains=%s&token=%s&%s" // API address for Duck DNS
+const (
+	URL        = "https://www.duckdns.org/update?domains=%s&token=%s&%s" // API address for Duck DNS
+	duckDomain = "duckdns.org"
+)
 
 type DNSProvider struct {
 	configuration *settings.Settings
@@ -25,7 +28,11 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 	return provider.updateIP(domainName, subdomainName, ip)
 }
 
-func (provider *DNSProvider) updateIP(domainName, subdomainName, currentIP string) error {
+// updateURL returns the update URL of the Duck DNS name of the subdomain.
+// The name is the label under duckdns.org, so both the domain duckdns.org with
+// the subdomain "name" and the domain name.duckdns.org with the apex are accepted.
+// The deeper subdomains cannot be updated, Duck DNS serves them by the name itself.
+func (provider *DNSProvider) updateURL(domainName, subdomainName, currentIP string) (string, error) {
 	var ip string
 	if strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
 		ip = fmt.Sprintf("ip=%s", currentIP)
@@ -33,9 +40,25 @@ func (provider *DNSProvider) updateIP(domainName, subdomainName, currentIP strin
 		ip = fmt.Sprintf("ipv6=%s", currentIP)
 	}
 
+	hostname := utils.FQDN(domainName, subdomainName)
+	name, ok := strings.CutSuffix(hostname, "."+duckDomain)
+	if !ok || strings.Contains(name, ".") {
+		return "", fmt.Errorf("unsupported Duck DNS name %s, only the names directly under %s can be updated", hostname, duckDomain)
+	}
+
+	return fmt.Sprintf(URL, name, provider.configuration.LoginToken, ip), nil
+}
+
+func (provider *DNSProvider) updateIP(domainName, subdomainName, currentIP string) error {
+	url, err := provider.updateURL(domainName, subdomainName, currentIP)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
 	client := utils.GetHTTPClient(provider.configuration)
 	// update IP with HTTP GET request
-	resp, err := client.Get(fmt.Sprintf(URL, subdomainName, provider.configuration.LoginToken, ip))
+	resp, err := client.Get(url)
 	if err != nil {
 		// handle error
 		log.Fatalf("Failed to update sub domain: %s.%s, error: %s", domainName, subdomainName, err)
@@ -54,7 +77,7 @@ func (provider *DNSProvider) updateIP(domainName, subdomainName, currentIP strin
 		return err
 	}
 
-	log.Printf("IP updated to: %s", ip)
+	log.Printf("IP updated to: %s", currentIP)
 
 	return nil
 }
@@ -0,0 +1,52 @@
+package duck
+
+import (
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+func TestUpdateURL(t *testing.T) {
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{LoginToken: "token", IPType: "IPv4"})
+
+	tests := []struct {
+		domain, subdomain, expected string
+	}{
+		{"duckdns.org", "example", "https://www.duckdns.org/update?domains=example&token=token&ip=203.0.113.1"},
+		{"example.duckdns.org", "@", "https://www.duckdns.org/update?domains=example&token=token&ip=203.0.113.1"},
+		{"Example.DuckDNS.org", "", "https://www.duckdns.org/update?domains=example&token=token&ip=203.0.113.1"},
+	}
+
+	for _, tt := range tests {
+		got, err := provider.updateURL(tt.domain, tt.subdomain, "203.0.113.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tt.expected {
+			t.Errorf("updateURL(%q, %q) = %s, want %s", tt.domain, tt.subdomain, got, tt.expected)
+		}
+	}
+}
+
+func TestUpdateURLNestedName(t *testing.T) {
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{LoginToken: "token", IPType: "IPv4"})
+
+	tests := []struct {
+		domain, subdomain string
+	}{
+		{"example.duckdns.org", "www"},
+		{"duckdns.org", "a.example"},
+		{"example.duckdns.org", "*"},
+		{"duckdns.org", "@"},
+		{"example.com", "www"},
+	}
+
+	for _, tt := range tests {
+		if got, err := provider.updateURL(tt.domain, tt.subdomain, "203.0.113.1"); err == nil {
+			t.Errorf("updateURL(%q, %q) = %s, want an error", tt.domain, tt.subdomain, got)
+		}
+	}
+}
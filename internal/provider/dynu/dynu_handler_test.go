@@ -0,0 +1,28 @@
+package dynu
+
+import (
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+func TestUpdateURL(t *testing.T) {
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{Password: "secret", IPType: "IPv6"})
+	password := utils.GetMD5Hash("secret")
+
+	tests := []struct {
+		domain, subdomain, expected string
+	}{
+		{"example.dynu.net", "@", "https://api.dynu.com/nic/update?hostname=example.dynu.net&password=" + password + "&myipv6=2001:db8::1"},
+		{"example.dynu.net", "*", "https://api.dynu.com/nic/update?hostname=*.example.dynu.net&password=" + password + "&myipv6=2001:db8::1"},
+		{"example.dynu.net", "a.b", "https://api.dynu.com/nic/update?hostname=a.b.example.dynu.net&password=" + password + "&myipv6=2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		if got := provider.updateURL(utils.FQDN(tt.domain, tt.subdomain), "2001:db8::1"); got != tt.expected {
+			t.Errorf("updateURL(%q, %q) = %s, want %s", tt.domain, tt.subdomain, got, tt.expected)
+		}
+	}
+}
@@ -23,12 +23,13 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 }
 
 func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	hostname := subdomainName + "." + domainName
+	hostname := utils.FQDN(domainName, subdomainName)
 	client := utils.GetHTTPClient(provider.configuration)
 	return provider.update(client, hostname, ip)
 }
 
-func (provider *DNSProvider) update(client *http.Client, hostname string, currentIP string) error {
+// updateURL returns the update URL of the hostname.
+func (provider *DNSProvider) updateURL(hostname, currentIP string) string {
 	var ip string
 	if strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
 		ip = fmt.Sprintf("ipv4=%s", currentIP)
@@ -36,8 +37,12 @@ func (provider *DNSProvider) update(client *http.Client, hostname string, curren
 		ip = fmt.Sprintf("ipv6=%s", currentIP)
 	}
 
+	return fmt.Sprintf(URL, hostname, provider.configuration.LoginToken, ip)
+}
+
+func (provider *DNSProvider) update(client *http.Client, hostname string, currentIP string) error {
 	// update IP with HTTP GET request
-	url := fmt.Sprintf(URL, hostname, provider.configuration.LoginToken, ip)
+	url := provider.updateURL(hostname, currentIP)
 	log.Println("Update url: ", url)
 	resp, err := client.Get(url)
 	if err != nil {
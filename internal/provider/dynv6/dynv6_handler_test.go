@@ -0,0 +1,28 @@
+package dynv6
+
+import (
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+func TestUpdateURL(t *testing.T) {
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{LoginToken: "token", IPType: "IPv4"})
+
+	tests := []struct {
+		domain, subdomain, expected string
+	}{
+		{"example.dynv6.net", "@", "https://dynv6.com/api/update?hostname=example.dynv6.net&token=token&ipv4=203.0.113.1"},
+		{"example.dynv6.net", "www", "https://dynv6.com/api/update?hostname=www.example.dynv6.net&token=token&ipv4=203.0.113.1"},
+		{"example.dynv6.net", "a.b", "https://dynv6.com/api/update?hostname=a.b.example.dynv6.net&token=token&ipv4=203.0.113.1"},
+		{"bücher.dynv6.net", "@", "https://dynv6.com/api/update?hostname=xn--bcher-kva.dynv6.net&token=token&ipv4=203.0.113.1"},
+	}
+
+	for _, tt := range tests {
+		if got := provider.updateURL(utils.FQDN(tt.domain, tt.subdomain), "203.0.113.1"); got != tt.expected {
+			t.Errorf("updateURL(%q, %q) = %s, want %s", tt.domain, tt.subdomain, got, tt.expected)
+		}
+	}
+}
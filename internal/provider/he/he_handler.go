@@ -1,7 +1,6 @@
 package he
 
 import (
-	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -29,15 +28,7 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 
 // updateIP updates subdomain with current IP.
 func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error {
-	values := url.Values{}
-	if subDomain != utils.RootDomain {
-		values.Add("hostname", fmt.Sprintf("%s.%s", subDomain, domain))
-	} else {
-		values.Add("hostname", domain)
-	}
-
-	values.Add("password", provider.configuration.Password)
-	values.Add("myip", currentIP)
+	values := provider.updateValues(domain, subDomain, currentIP)
 
 	client := utils.GetHTTPClient(provider.configuration)
 	req, _ := http.NewRequest("POST", URL, strings.NewReader(values.Encode()))
@@ -55,3 +46,12 @@ func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error
 
 	return nil
 }
+
+// updateValues returns the form of the update of the canonical hostname of the subdomain.
+func (provider *DNSProvider) updateValues(domain, subDomain, currentIP string) url.Values {
+	values := url.Values{}
+	values.Add("hostname", utils.FQDN(domain, subDomain))
+	values.Add("password", provider.configuration.Password)
+	values.Add("myip", currentIP)
+	return values
+}
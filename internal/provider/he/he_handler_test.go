@@ -0,0 +1,33 @@
+package he
+
+import (
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+func TestUpdateValues(t *testing.T) {
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{Password: "secret"})
+
+	tests := []struct {
+		domain, subdomain, expected string
+	}{
+		{"example.com", "@", "example.com"},
+		{"example.com", "www", "www.example.com"},
+		{"example.com", "*", "*.example.com"},
+		{"example.com", "a.b", "a.b.example.com"},
+		{"bücher.example", "www", "www.xn--bcher-kva.example"},
+	}
+
+	for _, tt := range tests {
+		values := provider.updateValues(tt.domain, tt.subdomain, "203.0.113.1")
+		if got := values.Get("hostname"); got != tt.expected {
+			t.Errorf("hostname of (%q, %q) = %s, want %s", tt.domain, tt.subdomain, got, tt.expected)
+		}
+
+		if values.Get("password") != "secret" || values.Get("myip") != "203.0.113.1" {
+			t.Errorf("unexpected form: %s", values.Encode())
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/pchchv/goddns/internal/settings"
 	"github.com/pchchv/goddns/internal/utils"
@@ -14,6 +16,8 @@ import (
 
 const BaseURL = "https://dns.hetzner.com/api/v1/" // API address
 
+var errRecordNotFound = errors.New("no record matching value and type found")
+
 type Record struct {
 	Type   string `json:"type"`
 	ID     string `json:"id"`
@@ -26,23 +30,28 @@ type Record struct {
 type DNSProvider struct {
 	configuration *settings.Settings
 	client        *http.Client
+	API           string
 }
 
 // Init passes DNS settings and store it to the provider instance.
 func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
 	provider.client = utils.GetHTTPClient(provider.configuration)
+	provider.API = BaseURL
 }
 
 func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	zoneID, err := provider.getZoneID(domainName)
+	zoneID, err := provider.getZoneID(utils.ZoneName(domainName))
 	if err != nil {
 		log.Fatal("Failed to get ZoneID")
 		return err
 	}
 
-	record, err := provider.getRecord(subdomainName, zoneID, provider.configuration.IPType)
-	if err != nil {
+	record, err := provider.getRecord(recordName(domainName, subdomainName), zoneID, provider.configuration.IPType)
+	if errors.Is(err, errRecordNotFound) {
+		// the record is created again after it was deleted
+		return provider.UpdateRecordSet(domainName, subdomainName, utils.RecordSetUpdate{IPs: []string{ip}, Mode: utils.RecordSetReplace})
+	} else if err != nil {
 		log.Fatal("Failed to get Record")
 		return err
 	}
@@ -56,7 +65,7 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 }
 
 func (provider *DNSProvider) getData(endpoint string, param string, value string) ([]byte, error) {
-	req, _ := http.NewRequest("GET", BaseURL+endpoint, nil)
+	req, _ := http.NewRequest("GET", provider.API+endpoint, nil)
 	q := req.URL.Query()
 	q.Add(param, value)
 	req.URL.RawQuery = q.Encode()
@@ -72,33 +81,41 @@ func (provider *DNSProvider) getData(endpoint string, param string, value string
 }
 
 func (provider *DNSProvider) getZoneID(zoneName string) (string, error) {
-	type Zone struct {
-		ID string `json:"id"`
-	}
+	zone, err := provider.getZone(zoneName)
+	return zone.ID, err
+}
 
+// Zone is the DNS zone, the names of its records are relative to the name of the zone.
+type Zone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (provider *DNSProvider) getZone(zoneName string) (Zone, error) {
 	type GetAllZonesResponse struct {
 		Zones []Zone `json:"zones"`
 	}
 
 	respBody, err := provider.getData("zones", "name", zoneName)
 	if err != nil {
-		return "", err
+		return Zone{}, err
 	}
 
 	response := GetAllZonesResponse{}
 	if err = json.Unmarshal(respBody, &response); err != nil {
-		return "", err
+		return Zone{}, err
 	}
 
-	if len(response.Zones) == 0 {
-		return "", err
+	if len(response.Zones) != 1 {
+		return Zone{}, nil
 	}
 
-	if len(response.Zones) > 1 {
-		return "", err
+	zone := response.Zones[0]
+	if zone.Name == "" {
+		zone.Name = zoneName
 	}
 
-	return response.Zones[0].ID, nil
+	return zone, nil
 }
 
 func (provider *DNSProvider) getRecord(recordName string, zoneID string, Type string) (Record, error) {
@@ -142,28 +159,236 @@ func (provider *DNSProvider) getRecord(recordName string, zoneID string, Type st
 		return outRecord, nil
 	}
 
-	return outRecord, errors.New("no record matching value and type found")
+	return outRecord, errRecordNotFound
+}
+
+func (provider *DNSProvider) updateRecord(record Record) error {
+	recordJSON, _ := json.Marshal(record)
+	return provider.sendData("PUT", "records/"+record.ID, recordJSON)
+}
+
+// UpdateRecordSet publishes the addresses as the records of the hostname,
+// the records are created and deleted to match the set.
+func (provider *DNSProvider) UpdateRecordSet(domainName, subdomainName string, update utils.RecordSetUpdate) error {
+	name, hostname := recordName(domainName, subdomainName), utils.FQDN(domainName, subdomainName)
+	zoneID, err := provider.getZoneID(utils.ZoneName(domainName))
+	if err != nil {
+		return err
+	} else if zoneID == "" {
+		return errors.New("failed to find zone for domain: " + domainName)
+	}
+
+	records, err := provider.getRecords(zoneID, name)
+	if err != nil {
+		return err
+	}
+
+	var existing []string
+	recordIDs := map[string]string{}
+	for _, record := range records {
+		existing = append(existing, record.Value)
+		recordIDs[record.Value] = record.ID
+	}
+
+	recordType := provider.recordType()
+
+	add, remove := update.Diff(existing)
+	for _, ip := range add {
+		recordJSON, _ := json.Marshal(map[string]string{"type": recordType, "name": name, "value": ip, "zone_id": zoneID})
+		if err = provider.sendData("POST", "records", recordJSON); err != nil {
+			return err
+		}
+		log.Printf("Record [%s] created with IP address: %s", hostname, ip)
+	}
+
+	for _, ip := range remove {
+		if err = provider.sendData("DELETE", "records/"+recordIDs[ip], nil); err != nil {
+			return err
+		}
+		log.Printf("Record [%s] with IP address %s deleted", hostname, ip)
+	}
+
+	return nil
+}
+
+// DeleteRecords deletes the address records of the hostname.
+func (provider *DNSProvider) DeleteRecords(domainName, subdomainName string) error {
+	return provider.UpdateRecordSet(domainName, subdomainName, utils.RecordSetUpdate{Mode: utils.RecordSetReplace})
 }
 
-func (provider *DNSProvider) putData(endpoint string, location string, body []byte) error {
-	req, _ := http.NewRequest("PUT", BaseURL+endpoint+"/"+location, bytes.NewBuffer(body))
+func (provider *DNSProvider) sendData(method string, endpoint string, body []byte) error {
+	req, _ := http.NewRequest(method, provider.API+endpoint, bytes.NewBuffer(body))
 	req.Header.Add("Auth-API-Token", provider.configuration.LoginToken)
 	req.Header.Add("Content-Type", "application/json")
 	resp, err := provider.client.Do(req)
 	if err != nil {
-		log.Fatal("Fetch failed")
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Fatal("Got non 200 status code: ", resp.Status)
 		return errors.New("got non 200 status code " + resp.Status)
 	}
 
 	return nil
 }
 
-func (provider *DNSProvider) updateRecord(record Record) error {
+// UpsertRecord creates the record of the type or updates its value.
+func (provider *DNSProvider) UpsertRecord(domainName, subdomainName, recordType, value string, ttl int) error {
+	name, hostname := recordName(domainName, subdomainName), utils.FQDN(domainName, subdomainName)
+	zoneID, err := provider.getZoneID(utils.ZoneName(domainName))
+	if err != nil {
+		return err
+	} else if zoneID == "" {
+		return errors.New("failed to find zone for domain: " + domainName)
+	}
+
+	if recordType == utils.RecordTypeTXT && !strings.HasPrefix(value, `"`) {
+		value = `"` + value + `"`
+	}
+
+	records, err := provider.listRecords(zoneID)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if record.Name != name || record.Type != recordType {
+			continue
+		}
+
+		if record.Value == value {
+			log.Printf("Record OK: %s %s", hostname, recordType)
+			return nil
+		}
+
+		record.Value = value
+		if ttl > 0 {
+			record.TTL = int64(ttl)
+		}
+		return provider.updateRecord(record)
+	}
+
+	record := map[string]interface{}{"type": recordType, "name": name, "value": value, "zone_id": zoneID}
+	if ttl > 0 {
+		record["ttl"] = ttl
+	}
+
 	recordJSON, _ := json.Marshal(record)
-	return provider.putData("records", record.ID, recordJSON)
+	if err = provider.sendData("POST", "records", recordJSON); err != nil {
+		return err
+	}
+
+	log.Printf("Record [%s] %s created: %s", hostname, recordType, value)
+	return nil
+}
+
+// GetRecords returns the addresses of the hostname.
+func (provider *DNSProvider) GetRecords(domainName, subdomainName string) ([]string, error) {
+	zoneID, err := provider.getZoneID(utils.ZoneName(domainName))
+	if err != nil {
+		return nil, err
+	} else if zoneID == "" {
+		return nil, errors.New("failed to find zone for domain: " + domainName)
+	}
+
+	records, err := provider.getRecords(zoneID, recordName(domainName, subdomainName))
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []string
+	for _, record := range records {
+		ips = append(ips, record.Value)
+	}
+
+	return ips, nil
+}
+
+// getRecords returns the address records of the name in the zone.
+func (provider *DNSProvider) getRecords(zoneID, name string) ([]Record, error) {
+	all, err := provider.listRecords(zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	recordType := provider.recordType()
+	for _, record := range all {
+		if record.Name == name && record.Type == recordType {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+// listRecords returns all the records of the zone.
+func (provider *DNSProvider) listRecords(zoneID string) ([]Record, error) {
+	type GetRecordsResult struct {
+		Records []Record `json:"records"`
+	}
+
+	respBody, err := provider.getData("records", "zone_id", zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := GetRecordsResult{}
+	if err = json.Unmarshal(respBody, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Records, nil
+}
+
+// DiscoverRecords returns the subdomains which have an address record and a TXT record with the marker,
+// as Hetzner records have no comments.
+func (provider *DNSProvider) DiscoverRecords(domainName, marker string) ([]string, error) {
+	zone, err := provider.getZone(utils.ZoneName(domainName))
+	if err != nil {
+		return nil, err
+	} else if zone.ID == "" {
+		return nil, errors.New("failed to find zone for domain: " + domainName)
+	}
+
+	records, err := provider.listRecords(zone.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	marked := map[string]bool{}
+	for _, record := range records {
+		if record.Type == utils.RecordTypeTXT && strings.Trim(record.Value, `"`) == marker {
+			marked[record.Name] = true
+		}
+	}
+
+	var subdomains []string
+	recordType := provider.recordType()
+	for _, record := range records {
+		if record.Type != recordType || !marked[record.Name] {
+			continue
+		}
+
+		// the names are relative to the zone, the domain can be below its apex
+		subdomain, ok := utils.SubdomainOf(domainName, utils.FQDN(zone.Name, record.Name))
+		if ok && !slices.Contains(subdomains, subdomain) {
+			subdomains = append(subdomains, subdomain)
+		}
+	}
+
+	return subdomains, nil
+}
+
+// recordName returns the name of the record of the subdomain in the zone, "@" for the apex.
+func recordName(domainName, subdomainName string) string {
+	return utils.RecordName(domainName, subdomainName, utils.RootDomain)
+}
+
+func (provider *DNSProvider) recordType() string {
+	if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
+		return utils.IPTypeAAAA
+	}
+	return utils.IPTypeA
 }
@@ -0,0 +1,136 @@
+package hetzner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+func TestUpdateRecordSetNames(t *testing.T) {
+	var zones, created []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/zones":
+			zones = append(zones, r.URL.Query().Get("name"))
+			fmt.Fprint(w, `{"zones": [{"id": "z1"}]}`)
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, `{"records": [{"id": "1", "type": "A", "name": "www", "value": "203.0.113.1", "zone_id": "z1"}]}`)
+		case r.Method == http.MethodPost:
+			var record map[string]string
+			json.NewDecoder(r.Body).Decode(&record)
+			created = append(created, record["name"])
+		}
+	}))
+	defer server.Close()
+
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{})
+	provider.API = server.URL + "/"
+
+	update := utils.RecordSetUpdate{IPs: []string{"203.0.113.1"}, Mode: utils.RecordSetReplace}
+	for _, subdomain := range []string{"@", "WWW", "*", "a.b"} {
+		if err := provider.UpdateRecordSet("Bücher.example", subdomain, update); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fmt.Sprint(created) != "[@ * a.b]" {
+		t.Errorf("created records %v", created)
+	}
+
+	for _, zone := range zones {
+		if zone != "xn--bcher-kva.example" {
+			t.Errorf("zone name = %q", zone)
+		}
+	}
+}
+
+func TestUpsertRecordError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/zones":
+			fmt.Fprint(w, `{"zones": [{"id": "z1"}]}`)
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, `{"records": [{"id": "1", "type": "TXT", "name": "_heartbeat", "value": "\"old\"", "zone_id": "z1"}]}`)
+		default:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	}))
+	defer server.Close()
+
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{})
+	provider.API = server.URL + "/"
+
+	if err := provider.UpsertRecord("example.com", "_heartbeat", utils.RecordTypeTXT, "new", 0); err == nil {
+		t.Error("the rejected update should be returned as an error")
+	}
+}
+
+func TestDiscoverRecords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/zones" {
+			fmt.Fprint(w, `{"zones": [{"id": "z1"}]}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"records": [
+			{"id": "1", "type": "A", "name": "www", "value": "203.0.113.1"},
+			{"id": "2", "type": "TXT", "name": "www", "value": "\"goddns:managed\""},
+			{"id": "3", "type": "A", "name": "@", "value": "203.0.113.1"},
+			{"id": "4", "type": "TXT", "name": "@", "value": "v=spf1 -all"},
+			{"id": "5", "type": "TXT", "name": "ci", "value": "goddns:managed"}
+		]}`)
+	}))
+	defer server.Close()
+
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{})
+	provider.API = server.URL + "/"
+
+	subdomains, err := provider.DiscoverRecords("example.com", "goddns:managed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(subdomains) != "[www]" {
+		t.Errorf("discovered subdomains = %v", subdomains)
+	}
+}
+
+func TestDiscoverRecordsSubdomain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/zones" {
+			fmt.Fprint(w, `{"zones": [{"id": "z1", "name": "example.com"}]}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"records": [
+			{"id": "1", "type": "A", "name": "www.home", "value": "203.0.113.1"},
+			{"id": "2", "type": "TXT", "name": "www.home", "value": "goddns:managed"},
+			{"id": "3", "type": "A", "name": "Home", "value": "203.0.113.1"},
+			{"id": "4", "type": "TXT", "name": "Home", "value": "goddns:managed"},
+			{"id": "5", "type": "A", "name": "www", "value": "203.0.113.1"},
+			{"id": "6", "type": "TXT", "name": "www", "value": "goddns:managed"}
+		]}`)
+	}))
+	defer server.Close()
+
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{})
+	provider.API = server.URL + "/"
+
+	subdomains, err := provider.DiscoverRecords("home.example.com", "goddns:managed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(subdomains) != "[www @]" {
+		t.Errorf("discovered subdomains = %v", subdomains)
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"github.com/pchchv/goddns/internal/utils"
 )
 
-const URL = "https://%s:%s@infomaniak.com/nic/update?hostname=%s.%s&myip=%s" // API address
+const URL = "https://%s:%s@infomaniak.com/nic/update?hostname=%s&myip=%s" // API address
 
 type DNSProvider struct {
 	configuration *settings.Settings
@@ -29,12 +29,7 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 // updateIP update subdomain with current IP.
 func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error {
 	client := utils.GetHTTPClient(provider.configuration)
-	resp, err := client.Get(fmt.Sprintf(URL,
-		provider.configuration.Email,
-		provider.configuration.Password,
-		subDomain,
-		domain,
-		currentIP))
+	resp, err := client.Get(provider.updateURL(domain, subDomain, currentIP))
 	if err != nil {
 		log.Fatal("Failed to update sub domain:", subDomain)
 		return err
@@ -63,3 +58,12 @@ func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error
 
 	return nil
 }
+
+// updateURL returns the update URL of the canonical hostname of the subdomain.
+func (provider *DNSProvider) updateURL(domain, subDomain, currentIP string) string {
+	return fmt.Sprintf(URL,
+		provider.configuration.Email,
+		provider.configuration.Password,
+		utils.FQDN(domain, subDomain),
+		currentIP)
+}
@@ -40,32 +40,35 @@ type recordListResponse struct {
 type DNSProvider struct {
 	configuration *settings.Settings
 	client        *http.Client
+	API           string
 }
 
 // Init passes DNS settings and store it to the provider instance.
 func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
 	provider.client = utils.GetHTTPClient(provider.configuration)
+	provider.API = BaseURL
 }
 
 func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	zoneID, err := provider.getZoneID(domainName)
+	zoneID, err := provider.getZoneID(utils.ZoneName(domainName))
 	if err != nil {
 		return err
 	}
 
-	recordID, currIP, err := provider.getRecord(zoneID, subdomainName+"."+domainName)
+	hostname := utils.FQDN(domainName, subdomainName)
+	recordID, currIP, err := provider.getRecord(zoneID, hostname)
 	if err != nil {
 		return err
 	} else if currIP == ip {
 		return nil
 	}
 
-	return provider.updateRecord(zoneID, recordID, subdomainName+"."+domainName, ip)
+	return provider.updateRecord(zoneID, recordID, hostname, ip)
 }
 
 func (provider *DNSProvider) getData(endpoint string, params map[string]string) ([]byte, error) {
-	req, err := http.NewRequest(http.MethodGet, BaseURL+endpoint, nil)
+	req, err := http.NewRequest(http.MethodGet, provider.API+endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +88,7 @@ func (provider *DNSProvider) getData(endpoint string, params map[string]string)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("failed to get data from " + BaseURL + endpoint + ", status code: " + resp.Status)
+		return nil, errors.New("failed to get data from " + provider.API + endpoint + ", status code: " + resp.Status)
 	}
 	defer resp.Body.Close()
 
@@ -148,7 +151,7 @@ func (provider *DNSProvider) putData(endpoint string, params map[string]any) (er
 		}
 	}
 
-	req, err := http.NewRequest(http.MethodPut, BaseURL+endpoint, bytes.NewReader(body))
+	req, err := http.NewRequest(http.MethodPut, provider.API+endpoint, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,38 @@
+package ionos
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+func TestUpdateIPRecordNames(t *testing.T) {
+	var names []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/zones":
+			fmt.Fprint(w, `[{"id": "z1", "name": "xn--bcher-kva.example"}]`)
+		case r.Method == http.MethodGet:
+			names = append(names, r.URL.Query().Get("recordName"))
+			fmt.Fprint(w, `{"records": [{"id": "1", "content": "198.51.100.1"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{})
+	provider.API = server.URL + "/"
+	for _, subdomain := range []string{"@", "WWW", "*", "a.b"} {
+		if err := provider.UpdateIP("Bücher.example", subdomain, "203.0.113.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := "[xn--bcher-kva.example www.xn--bcher-kva.example *.xn--bcher-kva.example a.b.xn--bcher-kva.example]"
+	if fmt.Sprint(names) != expected {
+		t.Errorf("record names %v, want %s", names, expected)
+	}
+}
@@ -1,27 +1,22 @@
 package linode
 
 import (
-	"context"
 	"errors"
 	"log"
-	"net"
 	"net/http"
 	"time"
 
 	"github.com/pchchv/goddns/internal/settings"
-	"golang.org/x/net/proxy"
+	"github.com/pchchv/goddns/internal/utils"
 	"golang.org/x/oauth2"
 )
 
 func CreateHTTPClient(conf *settings.Settings) (*http.Client, error) {
-	var err error
-	transport := &http.Transport{}
-	if conf.UseProxy && conf.Socks5Proxy != "" {
-		transport, err = applyProxy(conf.Socks5Proxy, transport)
-		if err != nil {
-			log.Fatalf("Error connecting to proxy: '%s'", err)
-			log.Print("Continuing without proxy")
-		}
+	transport, err := utils.NewTransport(conf, utils.ComponentProvider)
+	if err != nil {
+		log.Printf("Error creating HTTP transport: '%s'", err)
+		log.Print("Continuing with the default transport")
+		transport = &http.Transport{}
 	}
 
 	if conf.LoginToken == "" {
@@ -48,24 +43,3 @@ func addBearerAuth(accessToken string, transport http.RoundTripper) http.RoundTr
 
 	return transportWithAuth
 }
-
-func applyProxy(proxyAddress string, transport *http.Transport) (*http.Transport, error) {
-	if proxyAddress == "" {
-		log.Print("Skipping proxy: proxy address is empty string")
-		return transport, nil
-	}
-
-	dialer, err := proxy.SOCKS5("tcp", proxyAddress, nil, proxy.Direct)
-	if err != nil {
-		return transport, err
-	}
-
-	log.Printf("Connected to proxy : %s", proxyAddress)
-
-	dialContext := func(_ context.Context, network, address string) (net.Conn, error) {
-		return dialer.Dial(network, address)
-	}
-
-	transport.DialContext = dialContext
-	return transport, nil
-}
@@ -26,25 +26,27 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 }
 
 func (provider *DNSProvider) UpdateIP(domain, subdomain, ip string) error {
-	if subdomain == utils.RootDomain {
-		subdomain = ""
-	}
-
-	domainID, err := provider.getDomainID(domain)
+	domainID, err := provider.getDomainID(utils.ZoneName(domain))
 	if err != nil {
 		return err
 	}
 
-	recordExists, recordID, err := provider.getDomainRecordID(domainID, subdomain)
+	name := recordName(domain, subdomain)
+	recordExists, recordID, err := provider.getDomainRecordID(domainID, name)
 	if err != nil {
 		return err
 	} else if !recordExists {
-		recordID, _ = provider.createDomainRecord(domainID, subdomain)
+		recordID, _ = provider.createDomainRecord(domainID, name)
 	}
 
 	return provider.updateDomainRecord(domainID, recordID, ip)
 }
 
+// recordName returns the name of the record of the subdomain, Linode names the apex with an empty name.
+func recordName(domain, subdomain string) string {
+	return utils.RecordName(domain, subdomain, "")
+}
+
 func (provider *DNSProvider) getDomainID(name string) (int, error) {
 	f := linodego.Filter{}
 	f.AddField(linodego.Eq, "domain", name)
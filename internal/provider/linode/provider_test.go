@@ -0,0 +1,21 @@
+package linode
+
+import "testing"
+
+func TestRecordName(t *testing.T) {
+	tests := []struct {
+		domain, subdomain, name string
+	}{
+		{"example.com", "@", ""},
+		{"example.com", "WWW", "www"},
+		{"example.com", "*", "*"},
+		{"example.com", "a.b", "a.b"},
+		{"example.com", "münchen", "xn--mnchen-3ya"},
+	}
+
+	for _, tt := range tests {
+		if name := recordName(tt.domain, tt.subdomain); name != tt.name {
+			t.Errorf("recordName(%q, %q) = %q, want %q", tt.domain, tt.subdomain, name, tt.name)
+		}
+	}
+}
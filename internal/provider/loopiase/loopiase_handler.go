@@ -12,7 +12,7 @@ import (
 	"github.com/pchchv/goddns/internal/utils"
 )
 
-const URL = "https://%s:%s@dyndns.loopia.se/?hostname=%s.%s&myip=%s" // API address
+const URL = "https://%s:%s@dyndns.loopia.se/?hostname=%s&myip=%s" // API address
 
 type DNSProvider struct {
 	configuration *settings.Settings
@@ -30,12 +30,7 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 // updateIP update subdomain with current IP.
 func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error {
 	client := utils.GetHTTPClient(provider.configuration)
-	resp, err := client.Get(fmt.Sprintf(URL,
-		provider.configuration.Email,
-		provider.configuration.Password,
-		subDomain,
-		domain,
-		currentIP))
+	resp, err := client.Get(provider.updateURL(domain, subDomain, currentIP))
 
 	if err != nil {
 		// handle error
@@ -66,3 +61,12 @@ func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error
 
 	return nil
 }
+
+// updateURL returns the update URL of the canonical hostname of the subdomain.
+func (provider *DNSProvider) updateURL(domain, subDomain, currentIP string) string {
+	return fmt.Sprintf(URL,
+		provider.configuration.Email,
+		provider.configuration.Password,
+		utils.FQDN(domain, subDomain),
+		currentIP)
+}
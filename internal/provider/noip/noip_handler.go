@@ -23,12 +23,13 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 }
 
 func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	hostname := subdomainName + "." + domainName
+	hostname := utils.FQDN(domainName, subdomainName)
 	client := utils.GetHTTPClient(provider.configuration)
 	return provider.update(client, hostname, subdomainName, ip)
 }
 
-func (provider *DNSProvider) update(client *http.Client, hostname, subdomain string, currentIP string) error {
+// updateURL returns the update URL of the hostname.
+func (provider *DNSProvider) updateURL(hostname, currentIP string) string {
 	var ip string
 	if strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
 		ip = fmt.Sprintf("myip=%s", currentIP)
@@ -36,12 +37,11 @@ func (provider *DNSProvider) update(client *http.Client, hostname, subdomain str
 		ip = fmt.Sprintf("myipv6=%s", currentIP)
 	}
 
-	req, _ := http.NewRequest("GET", fmt.Sprintf(
-		URL,
-		provider.configuration.Email,
-		provider.configuration.Password,
-		hostname,
-		ip), nil)
+	return fmt.Sprintf(URL, provider.configuration.Email, provider.configuration.Password, hostname, ip)
+}
+
+func (provider *DNSProvider) update(client *http.Client, hostname, subdomain string, currentIP string) error {
+	req, _ := http.NewRequest("GET", provider.updateURL(hostname, currentIP), nil)
 	if provider.configuration.UserAgent != "" {
 		req.Header.Add("User-Agent", provider.configuration.UserAgent)
 	}
@@ -3,6 +3,7 @@ package ovh
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"strings"
 
 	"github.com/ovh/go-ovh/ovh"
@@ -39,9 +40,11 @@ func (provider *DNSProvider) UpdateIP(domainName string, subdomainName string, i
 		return err
 	}
 
+	client.Client = utils.GetHTTPClient(provider.configuration)
+
 	var IDs []int
-	query := fmt.Sprintf("/domain/zone/%s/record?subDomain=%s", domainName, subdomainName)
-	if err = client.Get(query, &IDs); err != nil {
+	zone := utils.ZoneName(domainName)
+	if err = client.Get(recordQuery(domainName, subdomainName), &IDs); err != nil {
 		log.Fatal("Fetch error")
 		return err
 	}
@@ -54,7 +57,7 @@ func (provider *DNSProvider) UpdateIP(domainName string, subdomainName string, i
 	outrec := Record{}
 	for _, id := range IDs {
 		record := Record{}
-		if err = client.Get(fmt.Sprintf("/domain/zone/%s/record/%s", domainName, fmt.Sprint(id)), &record); err != nil {
+		if err = client.Get(fmt.Sprintf("/domain/zone/%s/record/%s", zone, fmt.Sprint(id)), &record); err != nil {
 			log.Fatal("Fetch error on get record: ", id)
 			return err
 		}
@@ -72,13 +75,13 @@ func (provider *DNSProvider) UpdateIP(domainName string, subdomainName string, i
 
 	// update IP
 	outrec.Value = ip
-	if err = client.Put(fmt.Sprintf("/domain/zone/%s/record/%s", domainName, fmt.Sprint(outrec.ID)), outrec, nil); err != nil {
+	if err = client.Put(fmt.Sprintf("/domain/zone/%s/record/%s", zone, fmt.Sprint(outrec.ID)), outrec, nil); err != nil {
 		log.Fatal("Error while Updating record: ", outrec.SubDomain, outrec.Zone)
 		return err
 	}
 
 	// refresh zone
-	if err = client.Post(fmt.Sprintf("/domain/zone/%s/refresh", domainName), nil, nil); err != nil {
+	if err = client.Post(fmt.Sprintf("/domain/zone/%s/refresh", zone), nil, nil); err != nil {
 		log.Fatal("Applying new records failed")
 		return err
 	}
@@ -86,6 +89,12 @@ func (provider *DNSProvider) UpdateIP(domainName string, subdomainName string, i
 	return nil
 }
 
+// recordQuery returns the query of the records of the subdomain, OVH names the apex with an empty subdomain.
+func recordQuery(domainName, subdomainName string) string {
+	name := utils.RecordName(domainName, subdomainName, "")
+	return fmt.Sprintf("/domain/zone/%s/record?subDomain=%s", utils.ZoneName(domainName), url.QueryEscape(name))
+}
+
 func (provider *DNSProvider) recordTypeToIPType(Type string) string {
 	if Type == utils.IPTypeAAAA {
 		return utils.IPV6
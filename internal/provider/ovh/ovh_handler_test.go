@@ -0,0 +1,21 @@
+package ovh
+
+import "testing"
+
+func TestRecordQuery(t *testing.T) {
+	tests := []struct {
+		domain, subdomain, query string
+	}{
+		{"example.com", "@", "/domain/zone/example.com/record?subDomain="},
+		{"Example.com", "WWW", "/domain/zone/example.com/record?subDomain=www"},
+		{"example.com", "*", "/domain/zone/example.com/record?subDomain=%2A"},
+		{"example.com", "a.b", "/domain/zone/example.com/record?subDomain=a.b"},
+		{"bücher.example", "www", "/domain/zone/xn--bcher-kva.example/record?subDomain=www"},
+	}
+
+	for _, tt := range tests {
+		if query := recordQuery(tt.domain, tt.subdomain); query != tt.query {
+			t.Errorf("recordQuery(%q, %q) = %q, want %q", tt.domain, tt.subdomain, query, tt.query)
+		}
+	}
+}
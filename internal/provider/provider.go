@@ -1,8 +1,37 @@
 package provider
 
-import "github.com/pchchv/goddns/internal/settings"
+import (
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
 
 type IDNSProvider interface {
 	Init(conf *settings.Settings)
 	UpdateIP(domainName, subdomainName, ip string) error
 }
+
+// IRecordSetProvider is implemented by the providers which can publish several addresses for a hostname.
+type IRecordSetProvider interface {
+	UpdateRecordSet(domainName, subdomainName string, update utils.RecordSetUpdate) error
+}
+
+// IRecordProvider is implemented by the providers which can publish the records of any type.
+type IRecordProvider interface {
+	UpsertRecord(domainName, subdomainName, recordType, value string, ttl int) error
+}
+
+// IRecordReader is implemented by the providers which can read the addresses of a hostname.
+type IRecordReader interface {
+	GetRecords(domainName, subdomainName string) ([]string, error)
+}
+
+// IRecordDeleter is implemented by the providers which can delete the address records of a hostname.
+type IRecordDeleter interface {
+	DeleteRecords(domainName, subdomainName string) error
+}
+
+// IRecordDiscoverer is implemented by the providers which can find the address records carrying a marker.
+// The subdomains of the records are returned, RootDomain for the apex.
+type IRecordDiscoverer interface {
+	DiscoverRecords(domainName, marker string) ([]string, error)
+}
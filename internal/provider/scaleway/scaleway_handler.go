@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/pchchv/goddns/internal/settings"
@@ -51,7 +52,7 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 }
 
 func (provider *DNSProvider) UpdateIP(domainName string, subdomainName string, ip string) error {
-	log.Printf("%s.%s - Start to update record IP...", subdomainName, domainName)
+	log.Printf("%s - Start to update record IP...", utils.FQDN(domainName, subdomainName))
 	if err := provider.updateIP(domainName, subdomainName, ip); err != nil {
 		log.Fatal(err)
 		return err
@@ -72,19 +73,48 @@ func (provider *DNSProvider) getRecordType() (string, error) {
 
 // updateIP update subdomain with current IP.
 func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error {
-	recordType, err := provider.getRecordType()
+	req, reqBody, err := provider.newRequest(domain, subDomain, currentIP)
 	if err != nil {
 		return err
 	}
 
+	hostname := utils.FQDN(domain, subDomain)
+	client := utils.GetHTTPClient(provider.configuration)
+	log.Printf("Requesting update for '%s': '%v'", hostname, reqBody)
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatal("Request error:", err)
+		return errors.New("failed to complete update request")
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Update failed for '%s': %s", hostname, string(body))
+		return errors.New("update IP failed with status " + strconv.Itoa(resp.StatusCode))
+	}
+
+	log.Printf("Update IP success for '%s': '%s'", hostname, string(body))
+
+	return nil
+}
+
+// newRequest returns the request which sets the record of the subdomain to the IP.
+func (provider *DNSProvider) newRequest(domain, subDomain, currentIP string) (*http.Request, DNSUpdateRequest, error) {
+	recordType, err := provider.getRecordType()
+	if err != nil {
+		return nil, DNSUpdateRequest{}, err
+	}
+
+	// Scaleway names the apex with an empty name
+	name := utils.RecordName(domain, subDomain, "")
 	reqBody := DNSUpdateRequest{Changes: []DNSChange{{SetRecord{
 		IDFields: IDFields{
-			Name: subDomain,
+			Name: name,
 			Type: recordType,
 		},
 		Records: []Record{
 			{
-				Name:    subDomain,
+				Name:    name,
 				Data:    currentIP,
 				TTL:     provider.configuration.Interval,
 				Comment: "Set by GoDNS",
@@ -93,31 +123,19 @@ func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error
 	}}}}
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return errors.New("failed to encode request body as json")
+		return nil, DNSUpdateRequest{}, errors.New("failed to encode request body as json")
+	}
+
+	req, err := http.NewRequest("PATCH", fmt.Sprintf(URL, utils.ZoneName(domain)), bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, DNSUpdateRequest{}, err
 	}
 
-	req, _ := http.NewRequest("PATCH", fmt.Sprintf(URL, domain), bytes.NewReader(jsonBody))
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("X-Auth-Token", provider.configuration.LoginToken)
 	if provider.configuration.UserAgent != "" {
 		req.Header.Add("User-Agent", provider.configuration.UserAgent)
 	}
 
-	client := utils.GetHTTPClient(provider.configuration)
-	log.Printf("Requesting update for '%s.%s': '%v'", subDomain, domain, reqBody)
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Fatal("Request error:", err)
-		return errors.New("failed to complete update request")
-	}
-
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Update failed for '%s.%s': %s", subDomain, domain, string(body))
-		return errors.New("update IP failed with status " + string(resp.StatusCode))
-	}
-
-	log.Printf("Update IP success for '%s.%s': '%s'", subDomain, domain, string(body))
-
-	return nil
+	return req, reqBody, nil
 }
@@ -0,0 +1,43 @@
+package scaleway
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+func TestNewRequest(t *testing.T) {
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{LoginToken: "token", IPType: "IPv4"})
+
+	tests := []struct {
+		domain, subdomain, url, name string
+	}{
+		{"example.com", "@", "https://api.scaleway.com/domain/v2beta1/dns-zones/example.com/records", ""},
+		{"Example.com", "WWW", "https://api.scaleway.com/domain/v2beta1/dns-zones/example.com/records", "www"},
+		{"example.com", "*", "https://api.scaleway.com/domain/v2beta1/dns-zones/example.com/records", "*"},
+		{"bücher.example", "münchen", "https://api.scaleway.com/domain/v2beta1/dns-zones/xn--bcher-kva.example/records", "xn--mnchen-3ya"},
+	}
+
+	for _, tt := range tests {
+		req, _, err := provider.newRequest(tt.domain, tt.subdomain, "203.0.113.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if req.URL.String() != tt.url {
+			t.Errorf("newRequest(%q, %q) URL = %s, want %s", tt.domain, tt.subdomain, req.URL, tt.url)
+		}
+
+		var body DNSUpdateRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		set := body.Changes[0].Set
+		if set.IDFields.Name != tt.name || set.Records[0].Name != tt.name || set.Records[0].Data != "203.0.113.1" {
+			t.Errorf("newRequest(%q, %q) record = %+v", tt.domain, tt.subdomain, set)
+		}
+	}
+}
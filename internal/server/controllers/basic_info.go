@@ -4,7 +4,6 @@ import (
 	"strings"
 
 	"github.com/gofiber/fiber/v3"
-	"github.com/pchchv/goddns/internal/settings"
 	"github.com/pchchv/goddns/internal/utils"
 	"github.com/pchchv/goddns/pkg/ip"
 )
@@ -14,31 +13,50 @@ type BasicInfo struct {
 	StartTime    int64             `json:"start_time"`
 	DomainNum    int               `json:"domain_num"`
 	SubDomainNum int               `json:"sub_domain_num"`
-	Domains      []settings.Domain `json:"domains"`
+	Domains      []DomainInfo      `json:"domains"`
 	PublicIP     string            `json:"public_ip"`
+	IPResult     *ip.IPResult      `json:"ip_result,omitempty"`
+	WANs         map[string]string `json:"wans,omitempty"`
 	IPMode       string            `json:"ip_mode"`
 	Provider     string            `json:"provider"`
 }
 
 func (c *Controller) GetSubDomains() (count int) {
-	// get the total number of all the sub domains
-	for _, domain := range c.config.Domains {
-		count += len(domain.SubDomains)
+	// get the total number of all the configured and discovered sub domains
+	for _, domain := range c.getDomainInfos() {
+		count += len(domain.SubDomains) + len(domain.DiscoveredSubDomains)
 	}
 	return
 }
 
 func (c *Controller) GetBasicInfo(ctx fiber.Ctx) error {
-	return ctx.JSON(BasicInfo{
+	helper := ip.GetIPHelperInstance(c.config)
+	info := BasicInfo{
 		Version:      utils.Version,
 		StartTime:    utils.StartTime,
 		DomainNum:    c.getDomains(),
 		SubDomainNum: c.GetSubDomains(),
-		Domains:      c.config.Domains,
-		PublicIP:     ip.GetIPHelperInstance(c.config).GetCurrentIP(),
+		Domains:      c.getDomainInfos(),
+		PublicIP:     helper.GetCurrentIP(),
+		WANs:         c.getWANIPs(),
 		IPMode:       strings.ToUpper(c.config.IPType),
 		Provider:     c.config.Provider,
-	})
+	}
+
+	if result, ok := helper.GetIPResult(); ok {
+		info.IPResult = &result
+	}
+
+	return ctx.JSON(info)
+}
+
+func (c *Controller) getWANIPs() map[string]string {
+	// get the current IP of every WAN source
+	ips := map[string]string{}
+	for _, wan := range c.config.WANs {
+		ips[wan.Name] = ip.GetWANIPHelper(c.config, wan.Name).GetCurrentIP()
+	}
+	return ips
 }
 
 func (c *Controller) getDomains() int {
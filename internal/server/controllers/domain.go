@@ -5,10 +5,25 @@ import (
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/state"
 )
 
+// DomainInfo is the configured domain with the subdomains discovered in the zone of the provider.
+type DomainInfo struct {
+	settings.Domain
+	DiscoveredSubDomains []string `json:"discovered_sub_domains,omitempty"`
+}
+
 func (c *Controller) GetDomains(ctx fiber.Ctx) error {
-	return ctx.JSON(c.config.Domains)
+	return ctx.JSON(c.getDomainInfos())
+}
+
+func (c *Controller) getDomainInfos() []DomainInfo {
+	infos := make([]DomainInfo, 0, len(c.config.Domains))
+	for _, domain := range c.config.Domains {
+		infos = append(infos, DomainInfo{Domain: domain, DiscoveredSubDomains: state.GetStore().Discovered(domain.DomainName)})
+	}
+	return infos
 }
 
 func (c *Controller) DeleteDomain(ctx fiber.Ctx) error {
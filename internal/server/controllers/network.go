@@ -8,16 +8,25 @@ import (
 	"github.com/pchchv/goddns/internal/utils"
 )
 
+// NetworkSettings are the network settings of the API,
+// the optional fields are only updated when they are present in the body.
 type NetworkSettings struct {
-	IPMode        string           `json:"ip_mode"`
-	IPUrls        []string         `json:"ip_urls"`
-	IPV6Urls      []string         `json:"ipv6_urls"`
-	UseProxy      bool             `json:"use_proxy"`
-	SkipSSLVerify bool             `json:"skip_ssl_verify"`
-	Socks5Proxy   string           `json:"socks5_proxy"`
-	Webhook       settings.Webhook `json:"webhook,omitempty"`
-	Resolver      string           `json:"resolver"`
-	IPInterface   string           `json:"ip_interface"`
+	IPMode        string             `json:"ip_mode"`
+	IPUrls        []settings.IPURL   `json:"ip_urls"`
+	IPV6Urls      []settings.IPURL   `json:"ipv6_urls"`
+	IPQuorum      *settings.IPQuorum `json:"ip_quorum,omitempty"`
+	UseProxy      bool               `json:"use_proxy"`
+	SkipSSLVerify bool               `json:"skip_ssl_verify"`
+	Socks5Proxy   string             `json:"socks5_proxy"`
+	Proxy         *settings.Proxy    `json:"proxy,omitempty"`
+	TLS           *settings.TLS      `json:"tls,omitempty"`
+	Webhook       settings.Webhook   `json:"webhook,omitempty"`
+	Resolver      string             `json:"resolver"`
+	Resolvers     *[]string          `json:"resolvers,omitempty"`
+	Authoritative *bool              `json:"authoritative_lookup,omitempty"`
+	IPInterface   string             `json:"ip_interface"`
+	IPSelect      *settings.IPSelect `json:"ip_select,omitempty"`
+	IPDetect      *settings.IPDetect `json:"ip_detect,omitempty"`
 }
 
 func (c *Controller) GetNetworkSettings(ctx fiber.Ctx) error {
@@ -25,12 +34,19 @@ func (c *Controller) GetNetworkSettings(ctx fiber.Ctx) error {
 		IPMode:        c.config.IPType,
 		IPUrls:        c.config.IPUrls,
 		IPV6Urls:      c.config.IPV6Urls,
+		IPQuorum:      &c.config.IPQuorum,
 		UseProxy:      c.config.UseProxy,
 		SkipSSLVerify: c.config.SkipSSLVerify,
 		Socks5Proxy:   c.config.Socks5Proxy,
+		Proxy:         &c.config.Proxy,
+		TLS:           &c.config.TLS,
 		Webhook:       c.config.Webhook,
 		Resolver:      c.config.Resolver,
+		Resolvers:     &c.config.Resolvers,
+		Authoritative: &c.config.Authoritative,
 		IPInterface:   c.config.IPInterface,
+		IPSelect:      &c.config.IPSelect,
+		IPDetect:      &c.config.IPDetect,
 	}
 
 	return ctx.JSON(settings)
@@ -65,6 +81,35 @@ func (c *Controller) UpdateNetworkSettings(ctx fiber.Ctx) error {
 	c.config.Resolver = settings.Resolver
 	c.config.IPInterface = settings.IPInterface
 
+	// the clients which do not know the optional settings keep them as they are
+	if settings.IPQuorum != nil {
+		c.config.IPQuorum = *settings.IPQuorum
+	}
+
+	if settings.Proxy != nil {
+		c.config.Proxy = *settings.Proxy
+	}
+
+	if settings.TLS != nil {
+		c.config.TLS = *settings.TLS
+	}
+
+	if settings.Resolvers != nil {
+		c.config.Resolvers = *settings.Resolvers
+	}
+
+	if settings.Authoritative != nil {
+		c.config.Authoritative = *settings.Authoritative
+	}
+
+	if settings.IPSelect != nil {
+		c.config.IPSelect = *settings.IPSelect
+	}
+
+	if settings.IPDetect != nil {
+		c.config.IPDetect = *settings.IPDetect
+	}
+
 	if err := c.config.SaveSettings(c.configPath); err != nil {
 		log.Fatalf("Failed to save settings: %s", err.Error())
 		return ctx.Status(500).SendString("Failed to save network settings")
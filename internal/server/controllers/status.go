@@ -0,0 +1,10 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/pchchv/goddns/internal/state"
+)
+
+func (c *Controller) GetStatus(ctx fiber.Ctx) error {
+	return ctx.JSON(state.GetStore().List())
+}
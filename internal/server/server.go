@@ -116,6 +116,9 @@ func (s *Server) initRoutes() {
 	route.Get("/network", s.controller.GetNetworkSettings)
 	route.Put("/network", s.controller.UpdateNetworkSettings)
 
+	// status of the record updates
+	route.Get("/status", s.controller.GetStatus)
+
 	// serve embedded files
 	s.app.Use("/", static.New("", static.Config{
 		FS:     os.DirFS("out"),
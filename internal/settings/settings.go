@@ -17,9 +17,133 @@ const (
 	extYML  = "yml"
 )
 
+// defaultDiscoveryMarker is the marker of the records discovered in the zone.
+const defaultDiscoveryMarker = "goddns:managed"
+
 type Domain struct {
-	DomainName string   `json:"domain_name" yaml:"domain_name"`
-	SubDomains []string `json:"sub_domains" yaml:"sub_domains"`
+	DomainName  string        `json:"domain_name" yaml:"domain_name"`
+	SubDomains  []string      `json:"sub_domains" yaml:"sub_domains"`
+	WAN         string        `json:"wan,omitempty" yaml:"wan,omitempty"`
+	LANHosts    []LANHost     `json:"lan_hosts,omitempty" yaml:"lan_hosts,omitempty"`
+	RecordSet   RecordSet     `json:"record_set,omitempty" yaml:"record_set,omitempty"`
+	Records     []ExtraRecord `json:"records,omitempty" yaml:"records,omitempty"`
+	RefreshDays int           `json:"refresh_days,omitempty" yaml:"refresh_days,omitempty"`
+	Lifecycle   Lifecycle     `json:"lifecycle,omitempty" yaml:"lifecycle,omitempty"`
+	Discovery   Discovery     `json:"discovery,omitempty" yaml:"discovery,omitempty"`
+}
+
+// Discovery finds more subdomains of the domain in the zone of the provider.
+// The address records carrying Marker in their metadata are kept updated
+// along with the configured subdomains.
+type Discovery struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Marker  string `json:"marker,omitempty" yaml:"marker,omitempty"`
+}
+
+// GetMarker returns the marker of the discovered records, goddns:managed if it is not set.
+func (d Discovery) GetMarker() string {
+	if d.Marker != "" {
+		return d.Marker
+	}
+
+	return defaultDiscoveryMarker
+}
+
+// ExtraRecord is the record of another type published along with the addresses of the domain.
+// The value is a template with CurrentIP, Hostname, Domain, AgentID and Timestamp.
+type ExtraRecord struct {
+	SubDomain string `json:"sub_domain" yaml:"sub_domain"`
+	Type      string `json:"type" yaml:"type"`
+	Value     string `json:"value" yaml:"value"`
+	TTL       int    `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+}
+
+// RecordSet publishes several addresses for each subdomain of the domain.
+// The addresses of the WANs are published together, the domain's own source is used if none is set.
+// In the merge mode the addresses published by other agents are kept.
+type RecordSet struct {
+	Enabled      bool     `json:"enabled" yaml:"enabled"`
+	Mode         string   `json:"mode,omitempty" yaml:"mode,omitempty"`
+	WANs         []string `json:"wans,omitempty" yaml:"wans,omitempty"`
+	RemoveOnStop bool     `json:"remove_on_stop,omitempty" yaml:"remove_on_stop,omitempty"`
+}
+
+// Lifecycle is what happens to the records of the subdomains when the agent stops
+// or no IP is detected for IPLostAfter seconds. The records are deleted or pointed
+// at the parking address, they are published again once the agent has an IP.
+type Lifecycle struct {
+	OnStop      string `json:"on_stop,omitempty" yaml:"on_stop,omitempty"`
+	OnIPLost    string `json:"on_ip_lost,omitempty" yaml:"on_ip_lost,omitempty"`
+	IPLostAfter int    `json:"ip_lost_after,omitempty" yaml:"ip_lost_after,omitempty"`
+	ParkingIP   string `json:"parking_ip,omitempty" yaml:"parking_ip,omitempty"`
+}
+
+// LANHost is a LAN host which address is built from the delegated prefix
+// and the interface identifier, given as a static suffix or as EUI-64 from the MAC.
+// The suffix can be combined with the MAC to set the subnet ID.
+// With Neighbor the address of the MAC is looked up in the kernel neighbor table instead.
+type LANHost struct {
+	SubDomain string `json:"sub_domain" yaml:"sub_domain"`
+	Suffix    string `json:"suffix,omitempty" yaml:"suffix,omitempty"`
+	MAC       string `json:"mac,omitempty" yaml:"mac,omitempty"`
+	Neighbor  bool   `json:"neighbor,omitempty" yaml:"neighbor,omitempty"`
+}
+
+// Prefix is the IPv6 prefix delegation mode,
+// the prefix of the detected IP is used for the LAN hosts.
+type Prefix struct {
+	Enabled       bool `json:"enabled" yaml:"enabled"`
+	PrefixLength  int  `json:"prefix_length" yaml:"prefix_length"`
+	NeighborGrace int  `json:"neighbor_grace" yaml:"neighbor_grace"` // in seconds
+}
+
+// IPURL is an IP echo service and the way to extract the IP from its response.
+// It can be written as a plain URL string.
+type IPURL struct {
+	URL      string `json:"url" yaml:"url"`
+	Extract  string `json:"extract,omitempty" yaml:"extract,omitempty"`
+	JSONPath string `json:"json_path,omitempty" yaml:"json_path,omitempty"`
+	Header   string `json:"header,omitempty" yaml:"header,omitempty"`
+	Regex    string `json:"regex,omitempty" yaml:"regex,omitempty"`
+}
+
+// ipURL is used to decode IPURL without calling its unmarshal methods.
+type ipURL IPURL
+
+func (u *IPURL) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &u.URL); err == nil {
+		return nil
+	}
+
+	return json.Unmarshal(data, (*ipURL)(u))
+}
+
+func (u IPURL) MarshalJSON() ([]byte, error) {
+	if u.isPlain() {
+		return json.Marshal(u.URL)
+	}
+
+	return json.Marshal(ipURL(u))
+}
+
+func (u *IPURL) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&u.URL); err == nil {
+		return nil
+	}
+
+	return unmarshal((*ipURL)(u))
+}
+
+func (u IPURL) MarshalYAML() (interface{}, error) {
+	if u.isPlain() {
+		return u.URL, nil
+	}
+
+	return ipURL(u), nil
+}
+
+func (u IPURL) isPlain() bool {
+	return u.Extract == "" && u.JSONPath == "" && u.Header == "" && u.Regex == ""
 }
 
 type Webhook struct {
@@ -35,12 +159,147 @@ type WebPanel struct {
 	Password string `json:"password" yaml:"password"`
 }
 
+// Mikrotik is the RouterOS device to get the IP from.
+// The address is http(s)://host for the REST API, api://host or api-ssl://host for the binary API.
 type Mikrotik struct {
-	Enabled   bool   `json:"enabled" yaml:"enabled"`
-	Addr      string `json:"addr" yaml:"addr"`
-	Username  string `json:"username" yaml:"username"`
-	Password  string `json:"password" yaml:"password"`
+	Enabled        bool   `json:"enabled" yaml:"enabled"`
+	Addr           string `json:"addr" yaml:"addr"`
+	Username       string `json:"username" yaml:"username"`
+	Password       string `json:"password" yaml:"password"`
+	Interface      string `json:"interface" yaml:"interface"`
+	Source         string `json:"source" yaml:"source"`
+	ExcludeDynamic bool   `json:"exclude_dynamic" yaml:"exclude_dynamic"`
+	SkipVerify     bool   `json:"skip_verify" yaml:"skip_verify"`
+	CAFile         string `json:"ca_file" yaml:"ca_file"`
+}
+
+// OpenWrt is the OpenWrt router to get the IP of the interface from with ubus,
+// the user needs the read access to network.interface in the rpcd ACL.
+type OpenWrt struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	Addr       string `json:"addr" yaml:"addr"`
+	Username   string `json:"username" yaml:"username"`
+	Password   string `json:"password" yaml:"password"`
+	Interface  string `json:"interface" yaml:"interface"`
+	SkipVerify bool   `json:"skip_verify" yaml:"skip_verify"`
+}
+
+// FritzBox is the AVM Fritz!Box router to get the IP from with TR-064.
+type FritzBox struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	Addr       string `json:"addr" yaml:"addr"`
+	Username   string `json:"username" yaml:"username"`
+	Password   string `json:"password" yaml:"password"`
+	SkipVerify bool   `json:"skip_verify" yaml:"skip_verify"`
+}
+
+type Proxy struct {
+	HTTPProxy    string   `json:"http_proxy" yaml:"http_proxy"`
+	Username     string   `json:"username" yaml:"username"`
+	Password     string   `json:"password" yaml:"password"`
+	PasswordFile string   `json:"password_file" yaml:"password_file"`
+	NoProxy      []string `json:"no_proxy" yaml:"no_proxy"`
+	Components   []string `json:"components" yaml:"components"`
+}
+
+type TLS struct {
+	CAFile   string `json:"ca_file" yaml:"ca_file"`
+	CertFile string `json:"cert_file" yaml:"cert_file"`
+	KeyFile  string `json:"key_file" yaml:"key_file"`
+}
+
+type Bind struct {
 	Interface string `json:"interface" yaml:"interface"`
+	Address   string `json:"address" yaml:"address"`
+}
+
+// WAN is the named uplink the IP is detected through. The online, STUN and interface
+// sources are used on it unless Sources are set, the router sources only if they are listed.
+type WAN struct {
+	Name    string `json:"name" yaml:"name"`
+	Bind    `yaml:",inline"`
+	Sources []IPSource `json:"sources,omitempty" yaml:"sources,omitempty"`
+}
+
+// IPSelect is the policy to select the address of the IP interface,
+// the Index-th address of the ones matching the CIDR is used.
+type IPSelect struct {
+	Policy string `json:"policy" yaml:"policy"`
+	CIDR   string `json:"cidr" yaml:"cidr"`
+	Index  int    `json:"index" yaml:"index"`
+}
+
+// IPSource is the source in the IP detection pipeline.
+// Timeout is in seconds, the source is repeated Retries times on failure.
+type IPSource struct {
+	Type    string `json:"type" yaml:"type"`
+	Timeout int    `json:"timeout" yaml:"timeout"`
+	Retries int    `json:"retries" yaml:"retries"`
+	Trust   string `json:"trust" yaml:"trust"`
+}
+
+// IPDetect is the ordered list of the IP sources and the fallback mode.
+// If no sources are set, the enabled ones are used in the default order.
+type IPDetect struct {
+	Sources  []IPSource `json:"sources" yaml:"sources"`
+	Fallback string     `json:"fallback" yaml:"fallback"`
+}
+
+// Debounce is the hysteresis of the IP changes. A new IP is published after it is seen
+// in ConfirmPolls consecutive polls and for MinDuration seconds, and every hostname
+// is updated at most MaxUpdates times in Window seconds.
+type Debounce struct {
+	Enabled      bool `json:"enabled" yaml:"enabled"`
+	ConfirmPolls int  `json:"confirm_polls" yaml:"confirm_polls"`
+	MinDuration  int  `json:"min_duration" yaml:"min_duration"`
+	MaxUpdates   int  `json:"max_updates" yaml:"max_updates"`
+	Window       int  `json:"window" yaml:"window"`
+}
+
+// DryRun reports the changes of the records without applying them.
+// The plan is printed in the text or JSON format.
+type DryRun struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Format  string `json:"format,omitempty" yaml:"format,omitempty"`
+}
+
+// Verify is the check of the record after the update. The record is polled every
+// Interval seconds until all the servers return the new address or Timeout seconds pass.
+type Verify struct {
+	Enabled  bool    `json:"enabled" yaml:"enabled"`
+	Timeout  int     `json:"timeout" yaml:"timeout"`
+	Interval int     `json:"interval" yaml:"interval"`
+	Webhook  Webhook `json:"webhook" yaml:"webhook"` // receives the status of the verified updates
+}
+
+type UPnP struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	GatewayURL string `json:"gateway_url" yaml:"gateway_url"`
+}
+
+type NATPMP struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Gateway string `json:"gateway" yaml:"gateway"`
+	PCP     bool   `json:"pcp" yaml:"pcp"`
+}
+
+type IPQuorum struct {
+	Enabled  bool `json:"enabled" yaml:"enabled"`
+	Sources  int  `json:"sources" yaml:"sources"`
+	Required int  `json:"required" yaml:"required"`
+}
+
+type IPFilter struct {
+	Enabled        bool     `json:"enabled" yaml:"enabled"`
+	Ranges         []string `json:"ranges" yaml:"ranges"`
+	CIDRs          []string `json:"cidrs" yaml:"cidrs"`
+	CGNATInterface string   `json:"cgnat_interface" yaml:"cgnat_interface"`
+}
+
+type STUN struct {
+	Enabled     bool     `json:"enabled" yaml:"enabled"`
+	Servers     []string `json:"servers" yaml:"servers"`
+	IPV6Servers []string `json:"ipv6_servers" yaml:"ipv6_servers"`
 }
 
 type TelegramNotify struct {
@@ -107,21 +366,44 @@ type Settings struct {
 	LoginTokenFile string   `json:"login_token_file" yaml:"login_token_file"`
 	Domains        []Domain `json:"domains" yaml:"domains"`
 	IPUrl          string   `json:"ip_url" yaml:"ip_url"`
-	IPUrls         []string `json:"ip_urls" yaml:"ip_urls"`
+	IPUrls         []IPURL  `json:"ip_urls" yaml:"ip_urls"`
 	IPV6Url        string   `json:"ipv6_url" yaml:"ipv6_url"`
-	IPV6Urls       []string `json:"ipv6_urls" yaml:"ipv6_urls"`
+	IPV6Urls       []IPURL  `json:"ipv6_urls" yaml:"ipv6_urls"`
+	IPQuorum       IPQuorum `json:"ip_quorum" yaml:"ip_quorum"`
+	IPFilter       IPFilter `json:"ip_filter" yaml:"ip_filter"`
+	Prefix         Prefix   `json:"prefix_delegation" yaml:"prefix_delegation"`
 	Interval       int      `json:"interval" yaml:"interval"`
 	UserAgent      string   `json:"user_agent,omitempty" yaml:"user_agent,omitempty"`
 	Socks5Proxy    string   `json:"socks5_proxy" yaml:"socks5_proxy"`
+	Proxy          Proxy    `json:"proxy" yaml:"proxy"`
+	TLS            TLS      `json:"tls" yaml:"tls"`
 	Notify         Notify   `json:"notify" yaml:"notify"`
 	Webhook        Webhook  `json:"webhook,omitempty" yaml:"webhook,omitempty"`
 	IPInterface    string   `json:"ip_interface" yaml:"ip_interface"`
+	IPSelect       IPSelect `json:"ip_select" yaml:"ip_select"`
+	IPDetect       IPDetect `json:"ip_detect" yaml:"ip_detect"`
+	Debounce       Debounce `json:"debounce" yaml:"debounce"`
+	Verify         Verify   `json:"verify" yaml:"verify"`
+	Bind           Bind     `json:"bind" yaml:"bind"`
+	BindProviders  bool     `json:"bind_providers" yaml:"bind_providers"`
+	WANs           []WAN    `json:"wans" yaml:"wans"`
 	IPType         string   `json:"ip_type" yaml:"ip_type"`
 	Mikrotik       Mikrotik `json:"mikrotik" yaml:"mikrotik"`
+	OpenWrt        OpenWrt  `json:"openwrt" yaml:"openwrt"`
+	FritzBox       FritzBox `json:"fritzbox" yaml:"fritzbox"`
+	UPnP           UPnP     `json:"upnp" yaml:"upnp"`
+	NATPMP         NATPMP   `json:"nat_pmp" yaml:"nat_pmp"`
+	STUN           STUN     `json:"stun" yaml:"stun"`
 	Resolver       string   `json:"resolver" yaml:"resolver"`
+	Resolvers      []string `json:"resolvers" yaml:"resolvers"`
+	Authoritative  bool     `json:"authoritative_lookup" yaml:"authoritative_lookup"`
 	UseProxy       bool     `json:"use_proxy" yaml:"use_proxy"`
+	AgentID        string   `json:"agent_id,omitempty" yaml:"agent_id,omitempty"`
 	DebugInfo      bool     `json:"debug_info" yaml:"debug_info"`
 	RunOnce        bool     `json:"run_once" yaml:"run_once"`
+	DryRun         DryRun   `json:"dry_run" yaml:"dry_run"`
+	RefreshDays    int      `json:"refresh_days,omitempty" yaml:"refresh_days,omitempty"`
+	StateFile      string   `json:"state_file,omitempty" yaml:"state_file,omitempty"`
 	Proxied        bool     `json:"proxied" yaml:"proxied"`
 	AppKey         string   `json:"app_key" yaml:"app_key"`
 	AppSecret      string   `json:"app_secret" yaml:"app_secret"`
@@ -234,6 +516,10 @@ func loadSecretsFromFile(settings *Settings) (err error) {
 		return errors.New("failed to load login token from file: " + err.Error())
 	}
 
+	if settings.Proxy.Password, err = readSecretFromFile(settings.Proxy.PasswordFile, settings.Proxy.Password); err != nil {
+		return errors.New("failed to load proxy password from file: " + err.Error())
+	}
+
 	if settings.Notify.Slack.BotAPIToken, err = readSecretFromFile(settings.Notify.Slack.BotAPITokenFile, settings.Notify.Slack.BotAPIToken); err != nil {
 		return errors.New("failed to load slack api token from file: " + err.Error())
 	}
@@ -256,3 +542,41 @@ func loadSecretsFromFile(settings *Settings) (err error) {
 
 	return nil
 }
+
+// GetWAN returns the WAN source with the given name.
+func (s *Settings) GetWAN(name string) (WAN, bool) {
+	for _, wan := range s.WANs {
+		if wan.Name == name {
+			return wan, true
+		}
+	}
+
+	return WAN{}, false
+}
+
+// GetResolvers returns the DNS servers in the failover order,
+// the single resolver goes first.
+func (s *Settings) GetResolvers() []string {
+	var resolvers []string
+	if s.Resolver != "" {
+		resolvers = append(resolvers, s.Resolver)
+	}
+
+	for _, r := range s.Resolvers {
+		if r != "" {
+			resolvers = append(resolvers, r)
+		}
+	}
+
+	return resolvers
+}
+
+// GetAgentID returns the ID of the agent, the host name is used if it is not set.
+func (s *Settings) GetAgentID() string {
+	if s.AgentID != "" {
+		return s.AgentID
+	}
+
+	hostname, _ := os.Hostname()
+	return hostname
+}
@@ -1,6 +1,12 @@
 package settings
 
-import "testing"
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
 
 func TestLoadJSONSetting(t *testing.T) {
 	var settings Settings
@@ -29,3 +35,32 @@ func TestLoadYAMLSetting(t *testing.T) {
 
 	t.Log(settings)
 }
+
+func TestUnmarshalIPURLs(t *testing.T) {
+	jsonContent := `{"ip_urls": ["https://api.ipify.org", {"url": "https://ip.example.com", "extract": "json", "json_path": "ip"}]}`
+	yamlContent := "ip_urls:\n  - https://api.ipify.org\n  - url: https://ip.example.com\n    extract: json\n    json_path: ip\n"
+	for name, unmarshal := range map[string]func(*Settings) error{
+		"json": func(s *Settings) error { return json.Unmarshal([]byte(jsonContent), s) },
+		"yaml": func(s *Settings) error { return yaml.Unmarshal([]byte(yamlContent), s) },
+	} {
+		var settings Settings
+		if err := unmarshal(&settings); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		expected := []IPURL{{URL: "https://api.ipify.org"}, {URL: "https://ip.example.com", Extract: "json", JSONPath: "ip"}}
+		if !reflect.DeepEqual(settings.IPUrls, expected) {
+			t.Errorf("%s: expected %+v, got %+v", name, expected, settings.IPUrls)
+		}
+	}
+
+	// plain URLs are saved as strings
+	content, err := json.Marshal([]IPURL{{URL: "https://api.ipify.org"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != `["https://api.ipify.org"]` {
+		t.Errorf("unexpected JSON: %s", content)
+	}
+}
@@ -0,0 +1,155 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Hosts keeps the state of the managed hostnames in the file, so it survives a restart:
+// the times of the last updates used by the forced refresh, the addresses
+// the agent published in the record sets and the hostnames which records were released.
+// Without the file the state is kept in memory only.
+type Hosts struct {
+	mutex sync.Mutex
+	path  string
+	state hostsState
+}
+
+type hostsState struct {
+	Refreshes map[string]time.Time `json:"refreshes,omitempty"`
+	Published map[string][]string  `json:"published,omitempty"`
+	Released  map[string]bool      `json:"released,omitempty"`
+}
+
+// LoadHosts reads the state of the hostnames from the file, a missing file is empty.
+func LoadHosts(path string) (*Hosts, error) {
+	h := &Hosts{path: path}
+	if path == "" {
+		return h, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	} else if err != nil {
+		return h, err
+	}
+
+	if err = json.Unmarshal(content, &h.state); err != nil {
+		return h, err
+	}
+
+	return h, nil
+}
+
+// Last returns the time of the last update of the hostname, zero if it is unknown.
+func (h *Hosts) Last(hostname string) time.Time {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.state.Refreshes[hostname]
+}
+
+// Touch records the update of the hostname and saves the state to the file.
+func (h *Hosts) Touch(hostname string, t time.Time) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.state.Refreshes == nil {
+		h.state.Refreshes = map[string]time.Time{}
+	}
+
+	h.state.Refreshes[hostname] = t
+	return h.save()
+}
+
+// Published returns the addresses the agent published in the record set of the hostname.
+func (h *Hosts) Published(hostname string) []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.state.Published[hostname]
+}
+
+// SetPublished records the addresses published in the record set of the hostname
+// and saves the state to the file.
+func (h *Hosts) SetPublished(hostname string, ips []string) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.state.Published == nil {
+		h.state.Published = map[string][]string{}
+	}
+
+	if len(ips) == 0 {
+		delete(h.state.Published, hostname)
+	} else {
+		h.state.Published[hostname] = ips
+	}
+
+	return h.save()
+}
+
+// Released reports whether the records of the hostname were deleted or parked.
+func (h *Hosts) Released(hostname string) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.state.Released[hostname]
+}
+
+// SetReleased records whether the records of the hostname were released
+// and saves the state to the file if it changes.
+func (h *Hosts) SetReleased(hostname string, released bool) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.state.Released[hostname] == released {
+		return nil
+	}
+
+	if h.state.Released == nil {
+		h.state.Released = map[string]bool{}
+	}
+
+	if released {
+		h.state.Released[hostname] = true
+	} else {
+		delete(h.state.Released, hostname)
+	}
+
+	return h.save()
+}
+
+func (h *Hosts) save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(h.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the file is replaced at once, so a crash does not leave it truncated
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), h.path)
+}
@@ -0,0 +1,42 @@
+package state
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHostsPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	hosts, err := LoadHosts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !hosts.Last("www.example.com").IsZero() {
+		t.Error("time of the unknown hostname should be zero")
+	}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err = hosts.Touch("www.example.com", now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = hosts.SetPublished("api.example.com", []string{"192.0.2.1", "198.51.100.1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the state survives the restart
+	if hosts, err = LoadHosts(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if last := hosts.Last("www.example.com"); !last.Equal(now) {
+		t.Errorf("Last() = %s, want %s", last, now)
+	}
+
+	if ips := hosts.Published("api.example.com"); !slices.Equal(ips, []string{"192.0.2.1", "198.51.100.1"}) {
+		t.Errorf("Published() = %v", ips)
+	}
+}
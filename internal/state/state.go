@@ -0,0 +1,74 @@
+// Package state keeps the status of the record updates and the discovered subdomains.
+package state
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	instance *Store
+	once     sync.Once
+)
+
+// RecordStatus is the status of the last update of the hostname.
+type RecordStatus struct {
+	Hostname  string    `json:"hostname"`
+	IP        string    `json:"ip"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Store struct {
+	mutex      sync.RWMutex
+	records    map[string]RecordStatus
+	discovered map[string][]string
+}
+
+// GetStore returns the store shared by the handlers and the API.
+func GetStore() *Store {
+	once.Do(func() {
+		instance = &Store{records: map[string]RecordStatus{}, discovered: map[string][]string{}}
+	})
+
+	return instance
+}
+
+// Set replaces the status of the hostname.
+func (s *Store) Set(status RecordStatus) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.records[status.Hostname] = status
+}
+
+// List returns the statuses ordered by the hostname.
+func (s *Store) List() []RecordStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	statuses := make([]RecordStatus, 0, len(s.records))
+	for _, status := range s.records {
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Hostname < statuses[j].Hostname })
+	return statuses
+}
+
+// SetDiscovered replaces the subdomains of the domain discovered in the zone.
+func (s *Store) SetDiscovered(domainName string, subdomains []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.discovered[domainName] = subdomains
+}
+
+// Discovered returns the subdomains of the domain discovered in the zone.
+func (s *Store) Discovered(domainName string) []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.discovered[domainName]
+}
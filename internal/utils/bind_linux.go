@@ -0,0 +1,19 @@
+//go:build linux
+
+package utils
+
+import "syscall"
+
+// bindToDevice binds the socket to the network interface with SO_BINDTODEVICE.
+func bindToDevice(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(_, _ string, c syscall.RawConn) error {
+		var sockErr error
+		if err := c.Control(func(fd uintptr) {
+			sockErr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, iface)
+		}); err != nil {
+			return err
+		}
+
+		return sockErr
+	}
+}
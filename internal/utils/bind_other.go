@@ -0,0 +1,16 @@
+//go:build !linux
+
+package utils
+
+import (
+	"errors"
+	"syscall"
+)
+
+// bindToDevice is only supported on Linux,
+// the source address should be used on other platforms.
+func bindToDevice(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(_, _ string, _ syscall.RawConn) error {
+		return errors.New("binding to interface " + iface + " is only supported on Linux, use the bind address instead")
+	}
+}
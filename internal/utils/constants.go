@@ -40,6 +40,106 @@ const (
 	RootDomain = "@"
 )
 
+// methods to extract the IP from the response of the IP echo service
+const (
+	IPExtractHeader = "header" // IP is the value of the response header
+	IPExtractJSON   = "json"   // IP is the value at the JSON path of the response
+	IPExtractRegex  = "regex"  // IP is the first match or the first group of the custom regex
+	IPExtractText   = "text"   // response is the IP as plain text
+)
+
+// named address ranges which can be rejected by the IP filter
+const (
+	IPRangeBogon         = "bogon"
+	IPRangeCGNAT         = "cgnat"
+	IPRangeDocumentation = "documentation"
+	IPRangeLinkLocal     = "link_local"
+	IPRangePrivate       = "private"
+	IPRangeULA           = "ula"
+)
+
+// policies to select the address of the IP interface
+const (
+	IPSelectFirst           = "first"            // the first address in the kernel order
+	IPSelectLongestLifetime = "longest_lifetime" // the address with the longest preferred lifetime
+	IPSelectStable          = "stable"           // the stable addresses before the temporary ones
+)
+
+// sources of the IP on the Mikrotik device
+const (
+	MikrotikSourceAddress = "address" // /ip/address or /ipv6/address depending on the IP type
+	MikrotikSourceCloud   = "cloud"   // /ip/cloud, requires the DDNS of the device to be enabled
+)
+
+// types of the sources in the IP detection pipeline
+const (
+	IPSourceFritzBox  = "fritzbox"
+	IPSourceInterface = "interface"
+	IPSourceMikrotik  = "mikrotik"
+	IPSourceNATPMP    = "nat_pmp"
+	IPSourceOnline    = "online"
+	IPSourceOpenWrt   = "openwrt"
+	IPSourceSTUN      = "stun"
+	IPSourceUPnP      = "upnp"
+)
+
+// fallback modes of the IP detection pipeline
+const (
+	IPFallbackFirstSuccess = "first_success" // the first source in the order which succeeds
+	IPFallbackAllAgree     = "all_agree"     // all the sources are queried and have to agree
+	IPFallbackPreferLocal  = "prefer_local"  // the router and the interface sources go before the remote ones
+)
+
+// trust levels of the IP sources
+const (
+	IPTrustLow    = "low"    // used only if no other source succeeds
+	IPTrustNormal = "normal" // the default level
+	IPTrustHigh   = "high"   // wins when the sources disagree in the all_agree mode
+)
+
+// types of the extra records
+const (
+	RecordTypeCNAME = "CNAME"
+	RecordTypeHTTPS = "HTTPS"
+	RecordTypeSVCB  = "SVCB"
+	RecordTypeTXT   = "TXT"
+)
+
+// merge modes of the record sets
+const (
+	RecordSetReplace = "replace" // the record set is replaced by the addresses of the agent
+	RecordSetMerge   = "merge"   // the addresses of the agent are added, the others are kept
+	RecordSetRemove  = "remove"  // the addresses of the agent are removed, used on shutdown
+)
+
+// formats of the dry run plan
+const (
+	PlanFormatText = "text"
+	PlanFormatJSON = "json"
+)
+
+// actions of the dry run plan
+const (
+	PlanCreate = "create" // the hostname has no records
+	PlanUpdate = "update" // the records don't match the addresses
+	PlanNoop   = "no-op"  // the records match the addresses
+	PlanError  = "error"  // the records cannot be read
+)
+
+// actions on the records when the agent stops or loses its IP
+const (
+	LifecycleKeep   = "keep"   // the records are left as they are
+	LifecycleDelete = "delete" // the address records are deleted
+	LifecyclePark   = "park"   // the records point at the parking address
+)
+
+// statuses of the record after the update
+const (
+	UpdateApplied = "applied" // all the servers return the new address
+	UpdatePending = "pending" // some of the servers return the new address
+	UpdateFailed  = "failed"  // no server returns the new address
+)
+
 var (
 	StartTime = time.Now().Unix()
 	Version   = "v0.1"             // current version of GoDDNS
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+// NewDialer creates the dialer for the network which connects
+// from the configured source address and network interface.
+func NewDialer(bind settings.Bind, network string) (*net.Dialer, error) {
+	dialer := &net.Dialer{
+		Timeout:   time.Second * DefaultTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	if bind.Address != "" {
+		ip := net.ParseIP(bind.Address)
+		if ip == nil {
+			return nil, errors.New("invalid bind address: " + bind.Address)
+		}
+
+		if strings.HasPrefix(network, "udp") {
+			dialer.LocalAddr = &net.UDPAddr{IP: ip}
+		} else {
+			dialer.LocalAddr = &net.TCPAddr{IP: ip}
+		}
+	}
+
+	if bind.Interface != "" {
+		dialer.Control = bindToDevice(bind.Interface)
+	}
+
+	return dialer, nil
+}
+
+// BindDialContext returns the dial function which
+// connects from the configured source address and network interface.
+func BindDialContext(bind settings.Bind) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialer, err := NewDialer(bind, network)
+		if err != nil {
+			return nil, err
+		}
+
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// BindFor returns the source binding of the component's connections.
+func BindFor(conf *settings.Settings, component string) settings.Bind {
+	switch component {
+	case ComponentIPDetection:
+		return conf.Bind
+	case ComponentProvider:
+		if conf.BindProviders {
+			return conf.Bind
+		}
+	}
+
+	return settings.Bind{}
+}
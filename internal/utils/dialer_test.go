@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"net"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+func TestNewDialerBindAddress(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	dialer, err := NewDialer(settings.Bind{Address: "127.0.0.1"}, "tcp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := dialer.Dial("tcp4", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if local := conn.LocalAddr().(*net.TCPAddr); !local.IP.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("expected connection from 127.0.0.1, got %s", local.IP)
+	}
+
+	if _, err := NewDialer(settings.Bind{Address: "not an address"}, "tcp"); err == nil {
+		t.Error("invalid bind address should return error")
+	}
+}
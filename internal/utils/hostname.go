@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// WildcardLabel is the label of the wildcard records.
+const WildcardLabel = "*"
+
+// hostnameProfile converts the names to ASCII for the lookup,
+// the underscores of the service names like _acme-challenge are allowed.
+var hostnameProfile = idna.New(idna.MapForLookup(), idna.StrictDomainName(false), idna.Transitional(false))
+
+// Hostname is the name of the record of the subdomain in the zone of the domain.
+// The names are lower case ASCII, the internationalized labels are converted to punycode.
+type Hostname struct {
+	Domain    string
+	SubDomain string
+}
+
+// NewHostname returns the canonical name of the subdomain of the domain.
+// The subdomain is the apex if it is RootDomain or empty, it can be a wildcard
+// like "*" or "*.a" and have several labels like "a.b".
+func NewHostname(domainName, subdomainName string) (Hostname, error) {
+	domain, err := toASCII(domainName)
+	if err != nil {
+		return Hostname{}, fmt.Errorf("invalid domain %s: %w", domainName, err)
+	} else if domain == "" {
+		return Hostname{}, errors.New("domain should not be empty")
+	}
+
+	subdomain := RootDomain
+	if name := strings.Trim(subdomainName, "."); name != "" && name != RootDomain {
+		if subdomain, err = toASCII(name); err != nil {
+			return Hostname{}, fmt.Errorf("invalid subdomain %s of %s: %w", subdomainName, domainName, err)
+		}
+	}
+
+	return Hostname{Domain: domain, SubDomain: subdomain}, nil
+}
+
+// FQDN returns the canonical full name of the subdomain of the domain.
+// The names which cannot be converted to ASCII are only lower cased.
+func FQDN(domainName, subdomainName string) string {
+	return canonicalHostname(domainName, subdomainName).FQDN()
+}
+
+// RecordName returns the canonical name of the subdomain relative to the domain,
+// apex is the name of the apex used by the provider.
+func RecordName(domainName, subdomainName, apex string) string {
+	return canonicalHostname(domainName, subdomainName).Name(apex)
+}
+
+// ZoneName returns the canonical name of the domain.
+func ZoneName(domainName string) string {
+	return FQDN(domainName, RootDomain)
+}
+
+// SubdomainOf returns the subdomain of the domain the full name belongs to, RootDomain for the apex.
+func SubdomainOf(domainName, name string) (string, bool) {
+	zone := ZoneName(domainName)
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == zone {
+		return RootDomain, true
+	}
+
+	subdomain, ok := strings.CutSuffix(name, "."+zone)
+	return subdomain, ok && subdomain != ""
+}
+
+// FQDN returns the full name without the trailing dot, the domain itself for the apex.
+func (h Hostname) FQDN() string {
+	if h.IsApex() {
+		return h.Domain
+	}
+
+	return h.SubDomain + "." + h.Domain
+}
+
+// Relative returns the name relative to the domain, RootDomain for the apex.
+func (h Hostname) Relative() string {
+	return h.Name(RootDomain)
+}
+
+// Name returns the name relative to the domain with the apex marker of the provider.
+func (h Hostname) Name(apex string) string {
+	if h.IsApex() {
+		return apex
+	}
+
+	return h.SubDomain
+}
+
+// IsApex reports whether the name is the domain itself.
+func (h Hostname) IsApex() bool {
+	return h.SubDomain == "" || h.SubDomain == RootDomain
+}
+
+// IsWildcard reports whether the name is a wildcard.
+func (h Hostname) IsWildcard() bool {
+	return h.SubDomain == WildcardLabel || strings.HasPrefix(h.SubDomain, WildcardLabel+".")
+}
+
+func (h Hostname) String() string {
+	return h.FQDN()
+}
+
+func canonicalHostname(domainName, subdomainName string) Hostname {
+	h, err := NewHostname(domainName, subdomainName)
+	if err != nil {
+		return Hostname{Domain: strings.ToLower(domainName), SubDomain: strings.ToLower(subdomainName)}
+	}
+
+	return h
+}
+
+// toASCII converts the name to lower case ASCII label by label, the wildcard label is kept.
+func toASCII(name string) (string, error) {
+	labels := strings.Split(strings.Trim(strings.TrimSpace(name), "."), ".")
+	for i, label := range labels {
+		if label == WildcardLabel {
+			if i > 0 {
+				return "", errors.New("wildcard should be the leftmost label")
+			}
+			continue
+		}
+
+		if label == "" {
+			return "", errors.New("empty label")
+		}
+
+		ascii, err := hostnameProfile.ToASCII(label)
+		if err != nil {
+			return "", err
+		}
+		labels[i] = ascii
+	}
+
+	return strings.Join(labels, "."), nil
+}
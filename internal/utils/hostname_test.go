@@ -0,0 +1,57 @@
+package utils
+
+import "testing"
+
+func TestNewHostname(t *testing.T) {
+	tests := []struct {
+		domain, subdomain string
+		fqdn, relative    string
+		apex, wildcard    bool
+	}{
+		{"example.com", "@", "example.com", "@", true, false},
+		{"example.com", "", "example.com", "@", true, false},
+		{"Example.COM.", "WWW", "www.example.com", "www", false, false},
+		{"example.com", "*", "*.example.com", "*", false, true},
+		{"example.com", "*.dev", "*.dev.example.com", "*.dev", false, true},
+		{"example.com", "a.b", "a.b.example.com", "a.b", false, false},
+		{"example.com", "_acme-challenge", "_acme-challenge.example.com", "_acme-challenge", false, false},
+		{"bücher.example", "www", "www.xn--bcher-kva.example", "www", false, false},
+		{"example.com", "münchen", "xn--mnchen-3ya.example.com", "xn--mnchen-3ya", false, false},
+	}
+
+	for _, tt := range tests {
+		h, err := NewHostname(tt.domain, tt.subdomain)
+		if err != nil {
+			t.Errorf("NewHostname(%q, %q) error: %v", tt.domain, tt.subdomain, err)
+			continue
+		}
+
+		if h.FQDN() != tt.fqdn || h.Relative() != tt.relative || h.IsApex() != tt.apex || h.IsWildcard() != tt.wildcard {
+			t.Errorf("NewHostname(%q, %q) = %s %s apex=%v wildcard=%v", tt.domain, tt.subdomain, h.FQDN(), h.Relative(), h.IsApex(), h.IsWildcard())
+		}
+	}
+
+	for _, subdomain := range []string{"a.*", "a..b"} {
+		if _, err := NewHostname("example.com", subdomain); err == nil {
+			t.Errorf("subdomain %q should be invalid", subdomain)
+		}
+	}
+
+	for name, subdomain := range map[string]string{"example.com.": "@", "WWW.example.com": "www", "*.a.example.com": "*.a"} {
+		if sd, ok := SubdomainOf("example.com", name); !ok || sd != subdomain {
+			t.Errorf("SubdomainOf(%q) = %q, %v, want %q", name, sd, ok, subdomain)
+		}
+	}
+
+	if _, ok := SubdomainOf("example.com", "www.example.org"); ok {
+		t.Error("name of another domain should not be a subdomain")
+	}
+
+	if name := RecordName("example.com", "@", ""); name != "" {
+		t.Errorf("apex name with empty marker = %q", name)
+	}
+
+	if name := RecordName("Example.com", "WWW", ""); name != "www" {
+		t.Errorf("record name = %q", name)
+	}
+}
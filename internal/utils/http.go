@@ -1,46 +1,207 @@
 package utils
 
 import (
-	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"log"
-	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/pchchv/goddns/internal/settings"
+	"golang.org/x/net/http/httpproxy"
 	"golang.org/x/net/proxy"
 )
 
-// GetHTTPClient creates the HTTP client and return it.
+// Components issuing outgoing HTTP requests,
+// each of them can be routed through the proxy separately.
+const (
+	ComponentProvider     = "provider"
+	ComponentWebhook      = "webhook"
+	ComponentNotification = "notification"
+	ComponentIPDetection  = "ip_detection"
+)
+
+// defaultProxyComponents are routed through the proxy
+// when no components are listed in the proxy settings.
+var defaultProxyComponents = []string{ComponentProvider, ComponentWebhook, ComponentNotification}
+
+// GetHTTPClient creates the HTTP client used by DNS providers and return it.
+// If the client cannot be created, its requests fail with the error.
 func GetHTTPClient(conf *settings.Settings) *http.Client {
-	client := &http.Client{
-		Timeout: time.Second * DefaultTimeout,
+	client, err := GetHTTPClientFor(conf, ComponentProvider)
+	if err != nil {
+		log.Println(err)
+		return &http.Client{Transport: errorTransport{err}}
+	}
+
+	return client
+}
+
+// GetHTTPClientFor creates the HTTP client for the specific component and return it.
+func GetHTTPClientFor(conf *settings.Settings, component string) (*http.Client, error) {
+	transport, err := NewTransport(conf, component)
+	if err != nil {
+		return nil, fmt.Errorf("can't create the HTTP client for %s: %w", component, err)
+	}
+
+	return &http.Client{
+		Timeout:   time.Second * DefaultTimeout,
+		Transport: transport,
+	}, nil
+}
+
+// errorTransport fails all the requests with the error.
+type errorTransport struct {
+	err error
+}
+
+func (t errorTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// NewTransport creates the HTTP transport for the specific component
+// with the configured TLS settings, source binding and, if enabled for the component, the proxy.
+func NewTransport(conf *settings.Settings, component string) (*http.Transport, error) {
+	tlsConfig, err := GetTLSConfig(conf)
+	if err != nil {
+		return nil, err
 	}
 
-	if conf.UseProxy && conf.Socks5Proxy != "" {
-		log.Println("use socks5 proxy:" + conf.Socks5Proxy)
-		dialer, err := proxy.SOCKS5("tcp", conf.Socks5Proxy, nil, proxy.Direct)
+	bind := BindFor(conf, component)
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+	}
+
+	if bind != (settings.Bind{}) {
+		transport.DialContext = BindDialContext(bind)
+	}
+
+	if !UseProxyFor(conf, component) {
+		return transport, nil
+	}
+
+	if conf.Proxy.HTTPProxy != "" {
+		log.Printf("use http proxy for %s: %s", component, conf.Proxy.HTTPProxy)
+		proxyFunc, err := httpProxyFunc(conf)
 		if err != nil {
-			log.Fatal("can't connect to the proxy:", err)
-			return nil
+			return nil, err
 		}
 
-		dialContext := func(_ context.Context, network, address string) (net.Conn, error) {
-			return dialer.Dial(network, address)
+		transport.Proxy = proxyFunc
+		return transport, nil
+	}
+
+	log.Printf("use socks5 proxy for %s: %s", component, conf.Socks5Proxy)
+	dialer, err := socks5Dialer(conf, bind)
+	if err != nil {
+		return nil, err
+	}
+
+	transport.DialContext = dialer.DialContext
+	return transport, nil
+}
+
+// UseProxyFor reports whether requests of the component should go through the proxy.
+func UseProxyFor(conf *settings.Settings, component string) bool {
+	if !conf.UseProxy || (conf.Proxy.HTTPProxy == "" && conf.Socks5Proxy == "") {
+		return false
+	}
+
+	components := conf.Proxy.Components
+	if len(components) == 0 {
+		components = defaultProxyComponents
+	}
+
+	return slices.Contains(components, component)
+}
+
+// GetTLSConfig creates the TLS client config with
+// the custom CA bundle and the client certificate if they are set.
+func GetTLSConfig(conf *settings.Settings) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: conf.SkipSSLVerify}
+	if conf.TLS.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
 		}
 
-		httpTransport := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.SkipSSLVerify},
+		content, err := os.ReadFile(conf.TLS.CAFile)
+		if err != nil {
+			return nil, err
 		}
-		client.Transport = httpTransport
-		httpTransport.DialContext = dialContext
-	} else {
-		httpTransport := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.SkipSSLVerify},
+
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, errors.New("no certificates found in " + conf.TLS.CAFile)
 		}
-		client.Transport = httpTransport
+
+		tlsConfig.RootCAs = pool
 	}
 
-	return client
+	if conf.TLS.CertFile != "" || conf.TLS.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.TLS.CertFile, conf.TLS.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// httpProxyFunc returns the proxy selector for the HTTP(S) proxy.
+// Credentials from the proxy settings are used
+// unless the proxy URL already contains them.
+func httpProxyFunc(conf *settings.Settings) (func(*http.Request) (*url.URL, error), error) {
+	proxyURL, err := url.Parse(conf.Proxy.HTTPProxy)
+	if err != nil {
+		return nil, err
+	}
+
+	if proxyURL.User == nil && conf.Proxy.Username != "" {
+		proxyURL.User = url.UserPassword(conf.Proxy.Username, conf.Proxy.Password)
+	}
+
+	proxyConfig := &httpproxy.Config{
+		HTTPProxy:  proxyURL.String(),
+		HTTPSProxy: proxyURL.String(),
+		NoProxy:    strings.Join(conf.Proxy.NoProxy, ","),
+	}
+	proxyFunc := proxyConfig.ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}, nil
+}
+
+// socks5Dialer returns the SOCKS5 dialer,
+// hosts from the no_proxy list are dialed directly.
+func socks5Dialer(conf *settings.Settings, bind settings.Bind) (proxy.ContextDialer, error) {
+	var auth *proxy.Auth
+	if conf.Proxy.Username != "" {
+		auth = &proxy.Auth{
+			User:     conf.Proxy.Username,
+			Password: conf.Proxy.Password,
+		}
+	}
+
+	direct, err := NewDialer(bind, "tcp")
+	if err != nil {
+		return nil, err
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", conf.Socks5Proxy, auth, direct)
+	if err != nil {
+		return nil, err
+	}
+
+	perHost := proxy.NewPerHost(dialer, direct)
+	perHost.AddFromString(strings.Join(conf.Proxy.NoProxy, ","))
+	return perHost, nil
 }
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+func TestUseProxyFor(t *testing.T) {
+	conf := &settings.Settings{UseProxy: true, Socks5Proxy: "127.0.0.1:1080"}
+	if !UseProxyFor(conf, ComponentProvider) {
+		t.Error("providers should use the proxy by default")
+	}
+
+	if UseProxyFor(conf, ComponentIPDetection) {
+		t.Error("IP detection should not use the proxy by default")
+	}
+
+	conf.Proxy.Components = []string{ComponentIPDetection}
+	if !UseProxyFor(conf, ComponentIPDetection) || UseProxyFor(conf, ComponentWebhook) {
+		t.Error("only the listed components should use the proxy")
+	}
+
+	conf.UseProxy = false
+	if UseProxyFor(conf, ComponentIPDetection) {
+		t.Error("proxy should not be used when use_proxy is disabled")
+	}
+}
+
+func TestHTTPProxyTransport(t *testing.T) {
+	conf := &settings.Settings{
+		UseProxy: true,
+		Proxy: settings.Proxy{
+			HTTPProxy: "http://proxy.example.com:3128",
+			Username:  "user",
+			Password:  "secret",
+			NoProxy:   []string{"internal.example.com"},
+		},
+	}
+
+	transport, err := NewTransport(conf, ComponentProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "https://api.example.com/v1", nil)
+	proxyURL, err := transport.Proxy(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if proxyURL == nil || proxyURL.Host != "proxy.example.com:3128" {
+		t.Fatalf("expected proxy.example.com:3128, got %v", proxyURL)
+	}
+
+	if password, _ := proxyURL.User.Password(); proxyURL.User.Username() != "user" || password != "secret" {
+		t.Errorf("proxy credentials are not set: %v", proxyURL.User)
+	}
+
+	req, _ = http.NewRequest("GET", "https://internal.example.com/v1", nil)
+	if proxyURL, _ = transport.Proxy(req); proxyURL != nil {
+		t.Errorf("host from no_proxy should be dialed directly, got %v", proxyURL)
+	}
+}
+
+func TestGetTLSConfigInvalidCA(t *testing.T) {
+	conf := &settings.Settings{TLS: settings.TLS{CAFile: "./file/does/not/exists"}}
+	if _, err := GetTLSConfig(conf); err == nil {
+		t.Error("missing CA bundle should return error")
+	}
+}
+
+func TestGetHTTPClientInvalidCA(t *testing.T) {
+	conf := &settings.Settings{TLS: settings.TLS{CAFile: "./file/does/not/exists"}}
+	if _, err := GetHTTPClientFor(conf, ComponentWebhook); err == nil {
+		t.Error("missing CA bundle should return error")
+	}
+
+	if _, err := GetHTTPClient(conf).Get("https://example.com"); err == nil {
+		t.Error("request of the provider client should fail with the error")
+	}
+}
@@ -1,15 +1,25 @@
 package utils
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/miekg/dns"
 	"github.com/pchchv/goddns/pkg/resolver"
 )
 
+// ErrNoAuthoritativeAddress is returned when the authoritative name servers have no address of the hostname.
+var ErrNoAuthoritativeAddress = errors.New("no address on the authoritative name servers")
+
 // ResolveDNS will query DNS for a given hostname.
-func ResolveDNS(hostname, r, ipType string) (string, error) {
+// The resolvers are tried in order, see resolver.New for the supported addresses.
+func ResolveDNS(hostname string, resolvers []string, ipType string) (string, error) {
 	var dnsType uint16
 	if ipType == "" || strings.ToUpper(ipType) == IPV4 {
 		dnsType = dns.TypeA
@@ -19,16 +29,21 @@ func ResolveDNS(hostname, r, ipType string) (string, error) {
 
 	// if no DNS server is set in config file,
 	// falls back to default resolver
-	if r == "" {
-		dnsAddress, err := net.LookupHost(hostname)
+	if len(resolvers) == 0 {
+		network := "ip4"
+		if dnsType == dns.TypeAAAA {
+			network = "ip6"
+		}
+
+		dnsAddress, err := net.DefaultResolver.LookupIP(context.Background(), network, hostname)
 		if err != nil {
 			return "<nil>", err
 		}
 
-		return dnsAddress[0], nil
+		return dnsAddress[0].String(), nil
 	}
 
-	res := resolver.New([]string{r})
+	res := resolver.New(resolvers)
 	// in case of i/o timeout
 	res.RetryTimes = 5
 	ip, err := res.LookupHost(hostname, dnsType)
@@ -38,3 +53,85 @@ func ResolveDNS(hostname, r, ipType string) (string, error) {
 
 	return ip[0].String(), nil
 }
+
+// ResolveAuthoritative queries the authoritative name servers of the hostname's zone directly
+// and returns the addresses any of them answers with. The name servers are found
+// with the resolvers, or by walking from the root if there are none.
+func ResolveAuthoritative(hostname string, resolvers []string, ipType string) ([]string, error) {
+	dnsType := dns.TypeA
+	if strings.ToUpper(ipType) == IPV6 {
+		dnsType = dns.TypeAAAA
+	}
+
+	answers, err := resolver.New(resolvers).LookupAuthoritative(hostname, dnsType)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []string
+	distinct := map[string]bool{}
+	for _, answer := range answers {
+		set := make([]string, 0, len(answer))
+		for _, ip := range answer {
+			set = append(set, ip.String())
+		}
+		sort.Strings(set)
+
+		distinct[strings.Join(set, ",")] = true
+		for _, ip := range set {
+			if !slices.Contains(ips, ip) {
+				ips = append(ips, ip)
+			}
+		}
+	}
+
+	// the servers disagree while the update propagates to the secondary ones
+	if len(distinct) > 1 {
+		log.Printf("Authoritative name servers of %s disagree: %v", hostname, answers)
+	}
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoAuthoritativeAddress, hostname)
+	}
+
+	sort.Strings(ips)
+	return ips, nil
+}
+
+// VerifyRecord returns how many of the servers answer with the IP for the hostname.
+// The authoritative name servers are queried unless the resolvers are set
+// and the authoritative lookup is off, then every resolver is queried.
+func VerifyRecord(hostname, ip string, resolvers []string, ipType string, authoritative bool) (served, total int, err error) {
+	dnsType := dns.TypeA
+	if strings.ToUpper(ipType) == IPV6 {
+		dnsType = dns.TypeAAAA
+	}
+
+	contains := func(ips []net.IP) bool {
+		return slices.ContainsFunc(ips, func(addr net.IP) bool { return addr.String() == ip })
+	}
+
+	if authoritative || len(resolvers) == 0 {
+		answers, err := resolver.New(resolvers).LookupAuthoritative(hostname, dnsType)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for _, answer := range answers {
+			if contains(answer) {
+				served++
+			}
+		}
+
+		return served, len(answers), nil
+	}
+
+	for _, r := range resolvers {
+		// a failing resolver counts as not serving the IP yet
+		if ips, err := resolver.New([]string{r}).LookupHost(hostname, dnsType); err == nil && contains(ips) {
+			served++
+		}
+	}
+
+	return served, len(resolvers), nil
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// startDNSServer starts a resolver answering every A query with the IP.
+func startDNSServer(t *testing.T, ip string) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		m.Answer = []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+			A:   net.ParseIP(ip),
+		}}
+		_ = w.WriteMsg(m)
+	})}
+	go func() { _ = server.ActivateAndServe() }()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return conn.LocalAddr().String()
+}
+
+func TestVerifyRecord(t *testing.T) {
+	resolvers := []string{startDNSServer(t, "203.0.113.1"), startDNSServer(t, "198.51.100.1")}
+	served, total, err := VerifyRecord("www.example.com", "203.0.113.1", resolvers, IPV4, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if served != 1 || total != 2 {
+		t.Errorf("expected 1 of 2 resolvers to serve the IP, got %d of %d", served, total)
+	}
+}
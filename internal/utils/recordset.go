@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"slices"
+	"sort"
+)
+
+// RecordSetUpdate is the change of the addresses published for a hostname.
+// IPs are the current addresses of the agent, Previous are the ones it published before.
+type RecordSetUpdate struct {
+	IPs      []string
+	Previous []string
+	Mode     string
+}
+
+// Apply returns the sorted addresses of the record set after the update of the existing ones.
+func (u RecordSetUpdate) Apply(existing []string) []string {
+	var result []string
+	switch u.Mode {
+	case RecordSetReplace:
+		result = append(result, u.IPs...)
+	case RecordSetRemove:
+		for _, ip := range existing {
+			if !slices.Contains(u.IPs, ip) {
+				result = append(result, ip)
+			}
+		}
+	default:
+		// the stale addresses of the agent are replaced, the ones of the other agents are kept
+		for _, ip := range existing {
+			if !slices.Contains(u.Previous, ip) || slices.Contains(u.IPs, ip) {
+				result = append(result, ip)
+			}
+		}
+		result = append(result, u.IPs...)
+	}
+
+	sort.Strings(result)
+	return slices.Compact(result)
+}
+
+// Diff returns the addresses to add to and to remove from the existing ones.
+func (u RecordSetUpdate) Diff(existing []string) (add, remove []string) {
+	result := u.Apply(existing)
+	for _, ip := range result {
+		if !slices.Contains(existing, ip) {
+			add = append(add, ip)
+		}
+	}
+
+	for _, ip := range existing {
+		if !slices.Contains(result, ip) && !slices.Contains(remove, ip) {
+			remove = append(remove, ip)
+		}
+	}
+
+	return add, remove
+}
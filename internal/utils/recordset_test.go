@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRecordSetUpdateApply(t *testing.T) {
+	existing := []string{"198.51.100.2", "203.0.113.1", "203.0.113.9"}
+	tests := []struct {
+		name   string
+		update RecordSetUpdate
+		want   []string
+	}{
+		{"replace", RecordSetUpdate{IPs: []string{"203.0.113.5"}, Mode: RecordSetReplace}, []string{"203.0.113.5"}},
+		{"merge", RecordSetUpdate{IPs: []string{"203.0.113.5"}, Previous: []string{"203.0.113.1"}, Mode: RecordSetMerge}, []string{"198.51.100.2", "203.0.113.5", "203.0.113.9"}},
+		{"merge kept", RecordSetUpdate{IPs: []string{"203.0.113.1"}, Previous: []string{"203.0.113.1"}}, existing},
+		{"remove", RecordSetUpdate{IPs: []string{"203.0.113.1"}, Mode: RecordSetRemove}, []string{"198.51.100.2", "203.0.113.9"}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.update.Apply(existing); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Apply() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	add, remove := RecordSetUpdate{IPs: []string{"203.0.113.5"}, Previous: []string{"203.0.113.1"}}.Diff(existing)
+	if !slices.Equal(add, []string{"203.0.113.5"}) || !slices.Equal(remove, []string{"203.0.113.1"}) {
+		t.Errorf("Diff() = %v, %v", add, remove)
+	}
+}
@@ -3,6 +3,13 @@ package utils
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
 
 	"github.com/pchchv/goddns/internal/settings"
 )
@@ -107,19 +114,478 @@ func CheckSettings(config *settings.Settings) error {
 
 	}
 
+	if err := checkTLS(config); err != nil {
+		return err
+	}
+
+	if err := checkWANs(config); err != nil {
+		return err
+	}
+
+	if err := checkIPQuorum(config); err != nil {
+		return err
+	}
+
+	if err := checkIPFilter(config); err != nil {
+		return err
+	}
+
+	if err := checkMikrotik(config); err != nil {
+		return err
+	}
+
+	if err := checkRouters(config); err != nil {
+		return err
+	}
+
+	if err := checkIPSelect(config); err != nil {
+		return err
+	}
+
+	if err := checkIPDetect(config); err != nil {
+		return err
+	}
+
+	if err := checkDebounce(config); err != nil {
+		return err
+	}
+
+	if err := checkDryRun(config); err != nil {
+		return err
+	}
+
+	if err := checkResolvers(config); err != nil {
+		return err
+	}
+
+	if err := checkIPURLs(config.IPUrls); err != nil {
+		return err
+	}
+
+	if err := checkIPURLs(config.IPV6Urls); err != nil {
+		return err
+	}
+
+	if err := checkPrefix(config); err != nil {
+		return err
+	}
+
 	return checkDomains(config)
 }
 
+// checkTLS loads the CA bundle and the client certificate, so that unreadable files are reported on start.
+func checkTLS(config *settings.Settings) error {
+	if _, err := GetTLSConfig(config); err != nil {
+		return fmt.Errorf("invalid TLS settings: %w", err)
+	}
+
+	return nil
+}
+
+func checkWANs(config *settings.Settings) error {
+	names := map[string]bool{}
+	for _, wan := range config.WANs {
+		if wan.Name == "" {
+			return errors.New("WAN name should not be empty")
+		}
+
+		if names[wan.Name] {
+			return errors.New("duplicate WAN name: " + wan.Name)
+		}
+
+		if wan.Interface == "" && wan.Address == "" {
+			return errors.New("WAN " + wan.Name + " should have an interface or an address")
+		}
+
+		if err := checkIPSources(wan.Sources); err != nil {
+			return fmt.Errorf("WAN %s: %w", wan.Name, err)
+		}
+
+		names[wan.Name] = true
+	}
+
+	return nil
+}
+
+func checkIPQuorum(config *settings.Settings) error {
+	quorum := config.IPQuorum
+	if !quorum.Enabled {
+		return nil
+	}
+
+	if quorum.Sources < 0 || quorum.Required < 0 {
+		return errors.New("IP quorum sources and required answers should not be negative")
+	}
+
+	if quorum.Sources > 0 && quorum.Required > quorum.Sources {
+		return errors.New("IP quorum requires more answers than sources are queried")
+	}
+
+	return nil
+}
+
+func checkIPFilter(config *settings.Settings) error {
+	for _, name := range config.IPFilter.Ranges {
+		switch name {
+		case IPRangeBogon, IPRangeCGNAT, IPRangeDocumentation, IPRangeLinkLocal, IPRangePrivate, IPRangeULA:
+		default:
+			return fmt.Errorf("unknown IP filter range '%s'", name)
+		}
+	}
+
+	for _, cidr := range config.IPFilter.CIDRs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			return fmt.Errorf("invalid IP filter CIDR: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func checkMikrotik(config *settings.Settings) error {
+	if !config.Mikrotik.Enabled {
+		return nil
+	}
+
+	u, err := url.Parse(config.Mikrotik.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid mikrotik address: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "api", "api-ssl":
+	default:
+		return errors.New("mikrotik address should start with http://, https://, api:// or api-ssl://")
+	}
+
+	switch config.Mikrotik.Source {
+	case "", MikrotikSourceAddress:
+		if config.Mikrotik.Interface == "" {
+			return errors.New("mikrotik interface should not be empty")
+		}
+	case MikrotikSourceCloud:
+	default:
+		return fmt.Errorf("unknown mikrotik source '%s'", config.Mikrotik.Source)
+	}
+
+	return nil
+}
+
+func checkRouters(config *settings.Settings) error {
+	if config.OpenWrt.Enabled {
+		if err := checkRouterAddr("openwrt", config.OpenWrt.Addr); err != nil {
+			return err
+		}
+	}
+
+	// the address of the Fritz!Box defaults to http://fritz.box:49000
+	if config.FritzBox.Enabled && config.FritzBox.Addr != "" {
+		if err := checkRouterAddr("fritzbox", config.FritzBox.Addr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkRouterAddr(name, addr string) error {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return fmt.Errorf("invalid %s address: %w", name, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s address should start with http:// or https://", name)
+	}
+
+	return nil
+}
+
+func checkIPDetect(config *settings.Settings) error {
+	switch config.IPDetect.Fallback {
+	case "", IPFallbackFirstSuccess, IPFallbackAllAgree, IPFallbackPreferLocal:
+	default:
+		return fmt.Errorf("unknown IP fallback mode '%s'", config.IPDetect.Fallback)
+	}
+
+	return checkIPSources(config.IPDetect.Sources)
+}
+
+func checkIPSources(sources []settings.IPSource) error {
+	for _, source := range sources {
+		switch source.Type {
+		case IPSourceFritzBox, IPSourceInterface, IPSourceMikrotik, IPSourceNATPMP,
+			IPSourceOnline, IPSourceOpenWrt, IPSourceSTUN, IPSourceUPnP:
+		default:
+			return fmt.Errorf("unknown IP source type '%s'", source.Type)
+		}
+
+		switch source.Trust {
+		case "", IPTrustLow, IPTrustNormal, IPTrustHigh:
+		default:
+			return fmt.Errorf("unknown trust level '%s' of the IP source %s", source.Trust, source.Type)
+		}
+
+		if source.Timeout < 0 || source.Retries < 0 {
+			return errors.New("timeout and retries of the IP source " + source.Type + " should not be negative")
+		}
+	}
+
+	return nil
+}
+
+func checkDebounce(config *settings.Settings) error {
+	d := config.Debounce
+	if d.ConfirmPolls < 0 || d.MinDuration < 0 || d.MaxUpdates < 0 || d.Window < 0 {
+		return errors.New("debounce settings should not be negative")
+	}
+
+	if config.Verify.Timeout < 0 || config.Verify.Interval < 0 {
+		return errors.New("verify settings should not be negative")
+	}
+
+	if config.RefreshDays < 0 {
+		return errors.New("refresh days should not be negative")
+	}
+
+	return nil
+}
+
+func checkDryRun(config *settings.Settings) error {
+	switch config.DryRun.Format {
+	case "", PlanFormatText, PlanFormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("dry run format should be %s or %s", PlanFormatText, PlanFormatJSON)
+	}
+}
+
+func checkResolvers(config *settings.Settings) error {
+	for _, r := range config.GetResolvers() {
+		scheme, _, ok := strings.Cut(r, "://")
+		if !ok {
+			continue
+		}
+
+		switch scheme {
+		case "udp", "tcp", "tls", "https":
+		default:
+			return fmt.Errorf("resolver '%s' should start with udp://, tcp://, tls:// or https://", r)
+		}
+	}
+
+	return nil
+}
+
+func checkIPSelect(config *settings.Settings) error {
+	switch config.IPSelect.Policy {
+	case "", IPSelectFirst, IPSelectLongestLifetime, IPSelectStable:
+	default:
+		return fmt.Errorf("unknown IP select policy '%s'", config.IPSelect.Policy)
+	}
+
+	if config.IPSelect.CIDR != "" {
+		if _, err := netip.ParsePrefix(config.IPSelect.CIDR); err != nil {
+			return fmt.Errorf("invalid IP select CIDR: %w", err)
+		}
+	}
+
+	if config.IPSelect.Index < 0 {
+		return errors.New("IP select index should not be negative")
+	}
+
+	return nil
+}
+
+func checkIPURLs(urls []settings.IPURL) error {
+	for _, u := range urls {
+		switch u.Extract {
+		case "", IPExtractText:
+		case IPExtractJSON:
+			if u.JSONPath == "" {
+				return errors.New("JSON path of IP URL " + u.URL + " should not be empty")
+			}
+		case IPExtractHeader:
+			if u.Header == "" {
+				return errors.New("header of IP URL " + u.URL + " should not be empty")
+			}
+		case IPExtractRegex:
+			if u.Regex == "" {
+				return errors.New("regex of IP URL " + u.URL + " should not be empty")
+			}
+
+			if _, err := regexp.Compile(u.Regex); err != nil {
+				return fmt.Errorf("invalid regex of IP URL %s: %w", u.URL, err)
+			}
+		default:
+			return fmt.Errorf("unknown extract method '%s' of IP URL %s", u.Extract, u.URL)
+		}
+	}
+
+	return nil
+}
+
+func checkPrefix(config *settings.Settings) error {
+	if !config.Prefix.Enabled {
+		for _, d := range config.Domains {
+			if len(d.LANHosts) > 0 {
+				return errors.New("LAN hosts of domain " + d.DomainName + " require the prefix delegation mode")
+			}
+		}
+		return nil
+	}
+
+	if strings.ToUpper(config.IPType) != IPV6 {
+		return errors.New("prefix delegation mode requires the IPv6 IP type")
+	}
+
+	if length := config.Prefix.PrefixLength; length < 0 || length > 128 {
+		return fmt.Errorf("invalid prefix length %d", length)
+	}
+
+	for _, d := range config.Domains {
+		for _, host := range d.LANHosts {
+			if host.SubDomain == "" {
+				return errors.New("LAN host subdomain of domain " + d.DomainName + " should not be empty")
+			}
+
+			if host.Suffix == "" && host.MAC == "" {
+				return errors.New("LAN host " + host.SubDomain + " should have a suffix or a MAC")
+			}
+
+			if host.Neighbor && host.MAC == "" {
+				return errors.New("LAN host " + host.SubDomain + " should have a MAC to be found in the neighbor table")
+			}
+
+			if addr, err := netip.ParseAddr(host.Suffix); host.Suffix != "" && (err != nil || !addr.Is6()) {
+				return errors.New("invalid IPv6 suffix of LAN host " + host.SubDomain + ": " + host.Suffix)
+			}
+
+			if hw, err := net.ParseMAC(host.MAC); host.MAC != "" && (err != nil || len(hw) != 6) {
+				return errors.New("invalid MAC of LAN host " + host.SubDomain + ": " + host.MAC)
+			}
+		}
+	}
+
+	return nil
+}
+
 func checkDomains(config *settings.Settings) error {
 	for _, d := range config.Domains {
 		if d.DomainName == "" {
 			return errors.New("domain name should not be empty")
 		}
 
+		if _, ok := config.GetWAN(d.WAN); d.WAN != "" && !ok {
+			return errors.New("WAN " + d.WAN + " of domain " + d.DomainName + " is not configured")
+		}
+
+		if err := checkRecordSet(config, d); err != nil {
+			return err
+		}
+
+		if err := checkExtraRecords(d); err != nil {
+			return err
+		}
+
+		if d.RefreshDays < 0 {
+			return errors.New("refresh days of domain " + d.DomainName + " should not be negative")
+		}
+
+		if err := checkLifecycle(config, d); err != nil {
+			return err
+		}
+
 		for _, sd := range d.SubDomains {
 			if sd == "" {
 				return errors.New("subdomain should not be empty")
 			}
+
+			if _, err := NewHostname(d.DomainName, sd); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkRecordSet(config *settings.Settings, d settings.Domain) error {
+	rs := d.RecordSet
+	if !rs.Enabled {
+		return nil
+	}
+
+	switch rs.Mode {
+	case "", RecordSetReplace, RecordSetMerge:
+	default:
+		return fmt.Errorf("record set mode of domain %s should be %s or %s", d.DomainName, RecordSetReplace, RecordSetMerge)
+	}
+
+	for _, wan := range rs.WANs {
+		if _, ok := config.GetWAN(wan); !ok {
+			return errors.New("WAN " + wan + " of the record set of domain " + d.DomainName + " is not configured")
+		}
+	}
+
+	return nil
+}
+
+func checkLifecycle(config *settings.Settings, d settings.Domain) error {
+	lc := d.Lifecycle
+	for _, action := range []string{lc.OnStop, lc.OnIPLost} {
+		switch action {
+		case "", LifecycleKeep, LifecycleDelete:
+		case LifecyclePark:
+			addr, err := netip.ParseAddr(lc.ParkingIP)
+			if err != nil {
+				return fmt.Errorf("parking IP of domain %s is invalid: %w", d.DomainName, err)
+			}
+
+			if addr.Is4() == (strings.ToUpper(config.IPType) == IPV6) {
+				return errors.New("parking IP " + lc.ParkingIP + " of domain " + d.DomainName + " does not match the IP type")
+			}
+		default:
+			return fmt.Errorf("lifecycle action '%s' of domain %s should be %s, %s or %s", action, d.DomainName, LifecycleKeep, LifecycleDelete, LifecyclePark)
+		}
+	}
+
+	if lc.IPLostAfter < 0 {
+		return errors.New("IP lost duration of domain " + d.DomainName + " should not be negative")
+	} else if lc.IPLostAfter == 0 && lc.OnIPLost != "" && lc.OnIPLost != LifecycleKeep {
+		return errors.New("IP lost duration of domain " + d.DomainName + " should be set")
+	}
+
+	return nil
+}
+
+func checkExtraRecords(d settings.Domain) error {
+	for _, r := range d.Records {
+		if r.SubDomain == "" {
+			return errors.New("subdomain of the record of domain " + d.DomainName + " should not be empty")
+		}
+
+		switch r.Type {
+		case RecordTypeTXT, RecordTypeHTTPS, RecordTypeSVCB:
+		case RecordTypeCNAME:
+			hostname := FQDN(d.DomainName, r.SubDomain)
+			if hostname == ZoneName(d.DomainName) || slices.ContainsFunc(d.SubDomains, func(sd string) bool { return FQDN(d.DomainName, sd) == hostname }) {
+				return fmt.Errorf("CNAME record %s of domain %s conflicts with the address records", r.SubDomain, d.DomainName)
+			}
+		default:
+			return fmt.Errorf("record type '%s' should be %s, %s, %s or %s", r.Type, RecordTypeTXT, RecordTypeCNAME, RecordTypeHTTPS, RecordTypeSVCB)
+		}
+
+		if r.Value == "" {
+			return errors.New("value of the record " + r.SubDomain + " of domain " + d.DomainName + " should not be empty")
+		}
+
+		if _, err := template.New("record").Parse(r.Value); err != nil {
+			return fmt.Errorf("invalid value template of the record %s of domain %s: %w", r.SubDomain, d.DomainName, err)
+		}
+
+		if r.TTL < 0 {
+			return errors.New("TTL of the record " + r.SubDomain + " of domain " + d.DomainName + " should not be negative")
 		}
 	}
 
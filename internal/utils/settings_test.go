@@ -31,3 +31,70 @@ func TestCheckSettings(t *testing.T) {
 		t.Error("HE setting without password, should be faild")
 	}
 }
+
+func TestCheckWANSettings(t *testing.T) {
+	conf := &settings.Settings{
+		Provider:   "DNSPod",
+		LoginToken: "aaa",
+		WANs:       []settings.WAN{{Name: "wan1", Bind: settings.Bind{Interface: "eth1"}}},
+		Domains:    []settings.Domain{{DomainName: "example.com", SubDomains: []string{"wan1"}, WAN: "wan1"}},
+	}
+	if err := CheckSettings(conf); err != nil {
+		t.Errorf("domain mapped to a configured WAN should be passed: %s", err)
+	}
+
+	conf.Domains[0].WAN = "wan2"
+	if err := CheckSettings(conf); err == nil {
+		t.Error("domain mapped to an unknown WAN should be failed")
+	}
+
+	conf.Domains[0].WAN = ""
+	conf.WANs = append(conf.WANs, settings.WAN{Name: "wan1", Bind: settings.Bind{Address: "192.0.2.1"}})
+	if err := CheckSettings(conf); err == nil {
+		t.Error("duplicate WAN names should be failed")
+	}
+}
+
+func TestCheckLifecycleSettings(t *testing.T) {
+	conf := &settings.Settings{
+		Provider:   "DNSPod",
+		LoginToken: "aaa",
+		Domains: []settings.Domain{{DomainName: "example.com", SubDomains: []string{"www"}, Lifecycle: settings.Lifecycle{
+			OnStop:      LifecycleDelete,
+			OnIPLost:    LifecyclePark,
+			IPLostAfter: 600,
+			ParkingIP:   "192.0.2.1",
+		}}},
+	}
+	if err := CheckSettings(conf); err != nil {
+		t.Errorf("lifecycle should be passed: %s", err)
+	}
+
+	conf.IPType = IPV6
+	if err := CheckSettings(conf); err == nil {
+		t.Error("IPv4 parking address of IPv6 records should be failed")
+	}
+
+	conf.IPType = ""
+	conf.Domains[0].Lifecycle.IPLostAfter = 0
+	if err := CheckSettings(conf); err == nil {
+		t.Error("IP lost action without the duration should be failed")
+	}
+
+	conf.Domains[0].Lifecycle = settings.Lifecycle{OnStop: "drop"}
+	if err := CheckSettings(conf); err == nil {
+		t.Error("unknown lifecycle action should be failed")
+	}
+}
+
+func TestCheckTLSSettings(t *testing.T) {
+	conf := &settings.Settings{Provider: "DNSPod", LoginToken: "aaa", TLS: settings.TLS{CAFile: "./file/does/not/exists"}}
+	if err := CheckSettings(conf); err == nil {
+		t.Error("missing CA bundle should be failed")
+	}
+
+	conf.TLS = settings.TLS{CertFile: "./file/does/not/exists", KeyFile: "./file/does/not/exists"}
+	if err := CheckSettings(conf); err == nil {
+		t.Error("missing client certificate should be failed")
+	}
+}
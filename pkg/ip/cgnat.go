@@ -0,0 +1,118 @@
+package ip
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/pchchv/goddns/internal/utils"
+	"github.com/pchchv/goddns/pkg/notification"
+)
+
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// checkCGNAT compares the detected IP with the addresses of the WAN interface
+// and raises the warning once the host turns out to be behind carrier-grade NAT.
+func (helper *IPHelper) checkCGNAT(ip string) {
+	if strings.ToUpper(helper.configuration.IPType) == utils.IPV6 {
+		return
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return
+	}
+
+	iface := helper.configuration.IPFilter.CGNATInterface
+	if iface == "" {
+		iface = helper.ipInterface()
+	}
+
+	var local []netip.Addr
+	if iface != "" {
+		if local, err = interfaceAddrs(iface); err != nil {
+			log.Printf("Can't check carrier-grade NAT on %s: %s", iface, err)
+		}
+	}
+
+	behind, reason := detectCGNAT(addr.Unmap(), local)
+
+	helper.mutex.Lock()
+	changed := helper.cgnat != behind
+	helper.cgnat = behind
+	helper.mutex.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if !behind {
+		log.Println("Carrier-grade NAT is not detected anymore")
+		return
+	}
+
+	log.Println("Carrier-grade NAT detected:", reason)
+	if helper.configuration.DryRun.Enabled {
+		return
+	}
+
+	notification.GetNotificationManager(helper.configuration).SendEvent(notification.EventCGNAT, reason)
+}
+
+// detectCGNAT reports whether the detected IP shows that the host is behind carrier-grade NAT.
+// It is so if the IP itself or an address of the WAN interface is in the shared address space,
+// or if the WAN interface has public IPv4 addresses which differ from the detected IP.
+// The private addresses alone are the ordinary NAT of the home router.
+func detectCGNAT(addr netip.Addr, local []netip.Addr) (bool, string) {
+	if cgnatPrefix.Contains(addr) {
+		return true, fmt.Sprintf("the detected IP %s is in the shared address space %s", addr, cgnatPrefix)
+	}
+
+	var public []string
+	var assigned bool
+	for _, l := range local {
+		if !l.Is4() || l.IsLoopback() || l.IsLinkLocalUnicast() {
+			continue
+		}
+
+		if cgnatPrefix.Contains(l) {
+			return true, fmt.Sprintf("the interface address %s is in the shared address space %s, the public IP is %s", l, cgnatPrefix, addr)
+		}
+
+		if l == addr {
+			assigned = true
+		} else if !l.IsPrivate() {
+			public = append(public, l.String())
+		}
+	}
+
+	// the detected IP is assigned to the host directly
+	if assigned || len(public) == 0 {
+		return false, ""
+	}
+
+	return true, fmt.Sprintf("the interface addresses %s differ from the detected IP %s", strings.Join(public, ", "), addr)
+}
+
+func interfaceAddrs(name string) ([]netip.Addr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]netip.Addr, 0, len(addrs))
+	for _, addr := range addrs {
+		if prefix, err := netip.ParsePrefix(addr.String()); err == nil {
+			result = append(result, prefix.Addr().Unmap())
+		}
+	}
+
+	return result, nil
+}
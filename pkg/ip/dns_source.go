@@ -0,0 +1,93 @@
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/pchchv/goddns/internal/utils"
+	"github.com/pchchv/goddns/pkg/resolver"
+)
+
+// dnsScheme marks the IP URLs which are queried over DNS instead of HTTP.
+const dnsScheme = "dns"
+
+func isDNSSource(reqURL string) bool {
+	return strings.HasPrefix(strings.ToLower(reqURL), dnsScheme+"://")
+}
+
+// getIPFromDNS gets public IP from the DNS IP source.
+// The source is written as dns://server[:port]/name[?type=A|AAAA|TXT&class=IN|CH], e.g.:
+//
+//	dns://resolver1.opendns.com/myip.opendns.com
+//	dns://ns1.google.com/o-o.myaddr.l.google.com?type=TXT
+//	dns://1.1.1.1/whoami.cloudflare?type=TXT&class=CH
+//
+// The record type defaults to A or AAAA depending on the IP type.
+func (helper *IPHelper) getIPFromDNS(reqURL string) (string, error) {
+	u, err := url.Parse(reqURL)
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.Trim(u.Path, "/")
+	if u.Host == "" || name == "" {
+		return "", errors.New("DNS IP source should contain a server and a name: " + reqURL)
+	}
+
+	ipv6 := strings.ToUpper(helper.configuration.IPType) == utils.IPV6
+	qtype := dns.TypeA
+	network := "udp4"
+	if ipv6 {
+		qtype = dns.TypeAAAA
+		network = "udp6"
+	}
+
+	if t := u.Query().Get("type"); t != "" {
+		var ok bool
+		if qtype, ok = dns.StringToType[strings.ToUpper(t)]; !ok {
+			return "", errors.New("unknown DNS record type: " + t)
+		}
+	}
+
+	qclass := uint16(dns.ClassINET)
+	if c := u.Query().Get("class"); c != "" {
+		var ok bool
+		if qclass, ok = dns.StringToClass[strings.ToUpper(c)]; !ok {
+			return "", errors.New("unknown DNS class: " + c)
+		}
+	}
+
+	// the family of a server given by address is already known,
+	// a server given by name is reached over the family of the IP type
+	// so that the service sees the address which should be detected
+	if net.ParseIP(u.Hostname()) != nil {
+		network = "udp"
+	}
+
+	dialer, err := utils.NewDialer(helper.bind, network)
+	if err != nil {
+		return "", err
+	}
+
+	res := resolver.New([]string{u.Host})
+	res.Net = network
+	res.Dialer = dialer
+	ips, err := res.LookupIP(name, qtype, qclass)
+	if err != nil {
+		return "", fmt.Errorf("query %s: %w", reqURL, err)
+	}
+
+	for _, ip := range ips {
+		if (ip.To4() == nil) == ipv6 {
+			log.Printf("Get ip success by: %s, online IP: %s", reqURL, ip.String())
+			return ip.String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("query %s: no address of the expected type in %v", reqURL, ips)
+}
@@ -0,0 +1,103 @@
+package ip
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// startDNSServer starts a local DNS server answering
+// like OpenDNS, Google and Cloudflare "what is my IP" names.
+func startDNSServer(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := dns.NewServeMux()
+	mux.HandleFunc("myip.opendns.com.", func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		if r.Question[0].Qtype == dns.TypeA {
+			rr, _ := dns.NewRR("myip.opendns.com. 0 IN A 203.0.113.1")
+			m.Answer = append(m.Answer, rr)
+		}
+		_ = w.WriteMsg(m)
+	})
+	mux.HandleFunc("o-o.myaddr.l.google.com.", func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		rr, _ := dns.NewRR(`o-o.myaddr.l.google.com. 0 IN TXT "203.0.113.2"`)
+		m.Answer = append(m.Answer, rr)
+		_ = w.WriteMsg(m)
+	})
+	mux.HandleFunc("whoami.cloudflare.", func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		if r.Question[0].Qclass == dns.ClassCHAOS {
+			rr, _ := dns.NewRR(`whoami.cloudflare. 0 CH TXT "2001:db8::3"`)
+			m.Answer = append(m.Answer, rr)
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	started := make(chan struct{})
+	server := &dns.Server{PacketConn: conn, Handler: mux, NotifyStartedFunc: func() { close(started) }}
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = server.Shutdown() })
+
+	return conn.LocalAddr().String()
+}
+
+func TestGetIPFromDNS(t *testing.T) {
+	addr := startDNSServer(t)
+	tests := []struct {
+		url    string
+		ipType string
+		ip     string
+	}{
+		{"dns://" + addr + "/myip.opendns.com", utils.IPV4, "203.0.113.1"},
+		{"dns://" + addr + "/o-o.myaddr.l.google.com?type=TXT", utils.IPV4, "203.0.113.2"},
+		{"dns://" + addr + "/whoami.cloudflare?type=TXT&class=CH", utils.IPV6, "2001:db8::3"},
+	}
+
+	for _, tt := range tests {
+		helper := &IPHelper{configuration: &settings.Settings{IPType: tt.ipType}}
+		ip, err := helper.getIPFromDNS(tt.url)
+		if err != nil {
+			t.Errorf("%s: %s", tt.url, err)
+			continue
+		}
+
+		if ip != tt.ip {
+			t.Errorf("%s: expected %s, got %s", tt.url, tt.ip, ip)
+		}
+	}
+}
+
+func TestGetIPOnlineMixedWithDNS(t *testing.T) {
+	addr := startDNSServer(t)
+	helper := &IPHelper{idx: -1}
+	helper.UpdateConfiguration(&settings.Settings{
+		IPType: utils.IPV4,
+		IPUrls: []settings.IPURL{{URL: "dns://" + addr + "/myip.opendns.com"}},
+	})
+
+	if ip, _ := helper.getIPOnline(context.Background()); ip != "203.0.113.1" {
+		t.Errorf("expected 203.0.113.1, got %s", ip)
+	}
+}
+
+func TestGetIPFromDNSWrongFamily(t *testing.T) {
+	addr := startDNSServer(t)
+	helper := &IPHelper{configuration: &settings.Settings{IPType: utils.IPV4}}
+	if _, err := helper.getIPFromDNS("dns://" + addr + "/whoami.cloudflare?type=TXT&class=CH"); err == nil {
+		t.Error("IPv6 answer should be rejected in IPv4 mode")
+	}
+}
@@ -0,0 +1,110 @@
+package ip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+var ipReg = regexp.MustCompile(utils.IPPattern)
+
+// extractIP gets the IP from the response of the IP echo service
+// with the extract method of the source.
+func extractIP(source settings.IPURL, response *http.Response, body []byte) (string, error) {
+	switch source.Extract {
+	case utils.IPExtractText:
+		return strings.TrimSpace(string(body)), nil
+	case utils.IPExtractHeader:
+		value := response.Header.Get(source.Header)
+		if value == "" {
+			return "", errors.New("response has no header " + source.Header)
+		}
+		return strings.TrimSpace(value), nil
+	case utils.IPExtractJSON:
+		return extractJSONPath(body, source.JSONPath)
+	case utils.IPExtractRegex:
+		reg, err := regexp.Compile(source.Regex)
+		if err != nil {
+			return "", err
+		}
+
+		match := reg.FindStringSubmatch(string(body))
+		if match == nil {
+			return "", errors.New("response does not match " + source.Regex)
+		}
+
+		// the first group is the IP if the regex has groups
+		if len(match) > 1 {
+			return strings.TrimSpace(match[1]), nil
+		}
+		return strings.TrimSpace(match[0]), nil
+	default:
+		return ipReg.FindString(string(body)), nil
+	}
+}
+
+// extractJSONPath gets the string value at the dot separated path,
+// array elements are selected by index, e.g. "data.addresses.0".
+func extractJSONPath(body []byte, path string) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return "", err
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if value, ok = v[key]; !ok {
+				return "", fmt.Errorf("JSON path %s not found", path)
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", fmt.Errorf("JSON path %s not found", path)
+			}
+			value = v[i]
+		default:
+			return "", fmt.Errorf("JSON path %s not found", path)
+		}
+	}
+
+	ip, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("JSON value at %s is not a string", path)
+	}
+
+	return strings.TrimSpace(ip), nil
+}
+
+// parseIP validates the IP and checks that it is of the expected family.
+func parseIP(ip string, ipv6 bool) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	// the zone is only meaningful on the local link
+	if addr.Zone() != "" {
+		return netip.Addr{}, errors.New("IP with zone is not a public address: " + ip)
+	}
+
+	addr = addr.Unmap()
+	if addr.Is6() != ipv6 {
+		family := utils.IPV4
+		if ipv6 {
+			family = utils.IPV6
+		}
+		return netip.Addr{}, fmt.Errorf("IP %s is not %s", ip, family)
+	}
+
+	return addr, nil
+}
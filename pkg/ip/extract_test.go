@@ -0,0 +1,57 @@
+package ip
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+func TestGetIPFromURLExtract(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Client-IP", "203.0.113.3")
+		switch r.URL.Path {
+		case "/json":
+			_, _ = io.WriteString(w, `{"proxies":["198.51.100.1"],"client":{"addresses":["2001:db8::1","203.0.113.1"]}}`)
+		case "/text":
+			_, _ = io.WriteString(w, "203.0.113.2\n")
+		default:
+			_, _ = io.WriteString(w, "forwarded=198.51.100.1 remote=203.0.113.4")
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		source settings.IPURL
+		ip     string
+	}{
+		{settings.IPURL{URL: server.URL + "/json", Extract: utils.IPExtractJSON, JSONPath: "client.addresses.1"}, "203.0.113.1"},
+		{settings.IPURL{URL: server.URL + "/text", Extract: utils.IPExtractText}, "203.0.113.2"},
+		{settings.IPURL{URL: server.URL, Extract: utils.IPExtractHeader, Header: "X-Client-IP"}, "203.0.113.3"},
+		{settings.IPURL{URL: server.URL, Extract: utils.IPExtractRegex, Regex: `remote=(\S+)`}, "203.0.113.4"},
+		// wrong family and invalid values are rejected
+		{settings.IPURL{URL: server.URL + "/json", Extract: utils.IPExtractJSON, JSONPath: "client.addresses.0"}, ""},
+		{settings.IPURL{URL: server.URL, Extract: utils.IPExtractText}, ""},
+	}
+
+	helper := &IPHelper{configuration: &settings.Settings{IPType: utils.IPV4}}
+	for _, tt := range tests {
+		ip, err := helper.getIPFromURL(context.Background(), http.DefaultClient, tt.source)
+		if tt.ip == "" {
+			if err == nil {
+				t.Errorf("%+v: expected error, got %s", tt.source, ip)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%+v: %s", tt.source, err)
+		} else if ip != tt.ip {
+			t.Errorf("%+v: expected %s, got %s", tt.source, tt.ip, ip)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package ip
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// namedRanges are the address ranges which are not publicly routable.
+var namedRanges = map[string][]netip.Prefix{
+	utils.IPRangeBogon: mustParsePrefixes(
+		"0.0.0.0/8", "127.0.0.0/8", "192.0.0.0/24", "198.18.0.0/15", "224.0.0.0/4", "240.0.0.0/4",
+		"::/128", "::1/128", "64:ff9b:1::/48", "100::/64", "2001:10::/28", "ff00::/8",
+	),
+	utils.IPRangeCGNAT:         mustParsePrefixes("100.64.0.0/10"),
+	utils.IPRangeDocumentation: mustParsePrefixes("192.0.2.0/24", "198.51.100.0/24", "203.0.113.0/24", "2001:db8::/32", "3fff::/20"),
+	utils.IPRangeLinkLocal:     mustParsePrefixes("169.254.0.0/16", "fe80::/10"),
+	utils.IPRangePrivate:       mustParsePrefixes("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"),
+	utils.IPRangeULA:           mustParsePrefixes("fc00::/7"),
+}
+
+func mustParsePrefixes(cidrs ...string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, len(cidrs))
+	for i, cidr := range cidrs {
+		prefixes[i] = netip.MustParsePrefix(cidr)
+	}
+	return prefixes
+}
+
+// checkIP returns an error if the IP is in one of the ranges rejected by the IP filter.
+// All the named ranges are rejected if the filter does not list them.
+func (helper *IPHelper) checkIP(ip string) error {
+	filter := helper.configuration.IPFilter
+	if !filter.Enabled {
+		return nil
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return err
+	}
+	addr = addr.Unmap()
+
+	names := filter.Ranges
+	if len(names) == 0 {
+		for name := range namedRanges {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		for _, prefix := range namedRanges[name] {
+			if prefix.Contains(addr) {
+				return fmt.Errorf("IP %s is in the %s range %s", ip, name, prefix)
+			}
+		}
+	}
+
+	for _, cidr := range filter.CIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return err
+		}
+
+		if prefix.Contains(addr) {
+			return fmt.Errorf("IP %s is in the filtered range %s", ip, prefix)
+		}
+	}
+
+	return nil
+}
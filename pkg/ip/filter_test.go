@@ -0,0 +1,64 @@
+package ip
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+func TestCheckIP(t *testing.T) {
+	tests := []struct {
+		filter   settings.IPFilter
+		ip       string
+		rejected bool
+	}{
+		{settings.IPFilter{}, "100.64.1.1", false},
+		{settings.IPFilter{Enabled: true}, "100.64.1.1", true},
+		{settings.IPFilter{Enabled: true}, "192.168.1.1", true},
+		{settings.IPFilter{Enabled: true}, "fd00::1", true},
+		{settings.IPFilter{Enabled: true}, "::ffff:127.0.0.1", true},
+		{settings.IPFilter{Enabled: true}, "8.8.8.8", false},
+		{settings.IPFilter{Enabled: true}, "2606:4700::1111", false},
+		{settings.IPFilter{Enabled: true, Ranges: []string{utils.IPRangeCGNAT}}, "192.168.1.1", false},
+		{settings.IPFilter{Enabled: true, Ranges: []string{utils.IPRangeCGNAT}, CIDRs: []string{"8.8.8.0/24"}}, "8.8.8.8", true},
+	}
+
+	for _, tt := range tests {
+		helper := &IPHelper{configuration: &settings.Settings{IPFilter: tt.filter}}
+		if err := helper.checkIP(tt.ip); (err != nil) != tt.rejected {
+			t.Errorf("%s with %+v: expected rejected %v, got %v", tt.ip, tt.filter, tt.rejected, err)
+		}
+	}
+}
+
+func TestDetectCGNAT(t *testing.T) {
+	tests := []struct {
+		ip     string
+		local  []string
+		behind bool
+	}{
+		{"100.64.0.5", nil, true},
+		{"8.8.8.8", []string{"100.72.3.4"}, true},
+		{"8.8.8.8", []string{"9.9.9.9"}, true},
+		{"8.8.8.8", []string{"10.1.2.3", "fe80::1"}, false},
+		{"8.8.8.8", []string{"192.168.1.10", "9.9.9.9"}, true},
+		{"8.8.8.8", []string{"9.9.9.9", "8.8.8.8"}, false},
+		{"8.8.8.8", []string{"192.168.1.10", "8.8.8.8"}, false},
+		{"8.8.8.8", []string{"fe80::1"}, false},
+		// the LAN interface behind the home router has only the private addresses
+		{"8.8.8.8", []string{"192.168.1.10", "172.16.0.2"}, false},
+	}
+
+	for _, tt := range tests {
+		local := make([]netip.Addr, len(tt.local))
+		for i, l := range tt.local {
+			local[i] = netip.MustParseAddr(l)
+		}
+
+		if behind, _ := detectCGNAT(netip.MustParseAddr(tt.ip), local); behind != tt.behind {
+			t.Errorf("%s with %v: expected %v, got %v", tt.ip, tt.local, tt.behind, behind)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package ip
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+const (
+	fritzBoxDefaultAddr = "http://fritz.box:49000"
+	fritzBoxControlURL  = "/upnp/control/wanipconnection1"
+	fritzBoxService     = "urn:dslforum-org:service:WANIPConnection:1"
+)
+
+// getIPFromFritzBox gets WAN IP from the Fritz!Box with TR-064.
+func (helper *IPHelper) getIPFromFritzBox(ctx context.Context) (string, error) {
+	conf := helper.configuration.FritzBox
+	addr := conf.Addr
+	if addr == "" {
+		addr = fritzBoxDefaultAddr
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", fmt.Errorf("fail to parse fritzbox address: %w", err)
+	}
+	u.Path = path.Join(u.Path, fritzBoxControlURL)
+
+	ipv6 := strings.ToUpper(helper.configuration.IPType) == utils.IPV6
+	action, field := "GetExternalIPAddress", "NewExternalIPAddress"
+	if ipv6 {
+		action, field = "X_AVM_DE_GetExternalIPv6Address", "NewExternalIPv6Address"
+	}
+
+	client := helper.newRouterClient(conf.SkipVerify)
+	content, err := callTR064(ctx, client, u.String(), fritzBoxService, action, conf.Username, conf.Password)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := soapValue(content, field)
+	if err != nil {
+		return "", err
+	}
+
+	ip, err := parseIP(value, ipv6)
+	if err != nil {
+		return "", fmt.Errorf("fritzbox returned invalid address: %w", err)
+	}
+
+	if !ip.IsGlobalUnicast() {
+		return "", errors.New("fritzbox has no external address: " + value)
+	}
+
+	log.Printf("Get ip success from fritzbox: %s, IP: %s", u.Host, ip)
+	return ip.String(), nil
+}
+
+// callTR064 calls the SOAP action, the request is repeated with the digest auth if the device requires it.
+func callTR064(ctx context.Context, client *http.Client, controlURL, serviceType, action, username, password string) ([]byte, error) {
+	req, err := newSOAPRequest(ctx, controlURL, serviceType, action)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && username != "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		auth, err := digestAuthorization(challenge, username, password, req.Method, req.URL.RequestURI())
+		if err != nil {
+			return nil, err
+		}
+
+		if req, err = newSOAPRequest(ctx, controlURL, serviceType, action); err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Authorization", auth)
+		if resp, err = client.Do(req); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request %s got httpCode:%d, body: %s", controlURL, resp.StatusCode, string(content))
+	}
+
+	return content, nil
+}
+
+// soapValue returns the text of the first element with the name in the SOAP response.
+func soapValue(content []byte, name string) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", errors.New("no " + name + " in the SOAP response")
+		} else if err != nil {
+			return "", err
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == name {
+			var value string
+			if err = decoder.DecodeElement(&value, &start); err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(value), nil
+		}
+	}
+}
+
+// digestAuthorization answers the MD5 digest challenge (RFC 2617).
+func digestAuthorization(challenge, username, password, method, uri string) (string, error) {
+	scheme, rest, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return "", errors.New("unsupported authentication challenge: " + challenge)
+	}
+
+	params := parseAuthParams(rest)
+	if algorithm := params["algorithm"]; algorithm != "" && !strings.EqualFold(algorithm, "MD5") {
+		return "", errors.New("unsupported digest algorithm: " + algorithm)
+	}
+
+	ha1 := md5Hex(username + ":" + params["realm"] + ":" + password)
+	ha2 := md5Hex(method + ":" + uri)
+	auth := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s"`, username, params["realm"], params["nonce"], uri)
+
+	var qopAuth bool
+	for _, qop := range strings.Split(params["qop"], ",") {
+		qopAuth = qopAuth || strings.TrimSpace(qop) == "auth"
+	}
+
+	if qopAuth {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+
+		cnonce, nc := hex.EncodeToString(b), "00000001"
+		response := md5Hex(ha1 + ":" + params["nonce"] + ":" + nc + ":" + cnonce + ":auth:" + ha2)
+		auth += fmt.Sprintf(`, qop=auth, nc=%s, cnonce="%s", response="%s"`, nc, cnonce, response)
+	} else {
+		auth += fmt.Sprintf(`, response="%s"`, md5Hex(ha1+":"+params["nonce"]+":"+ha2))
+	}
+
+	if opaque, ok := params["opaque"]; ok {
+		auth += fmt.Sprintf(`, opaque="%s"`, opaque)
+	}
+
+	return auth, nil
+}
+
+// parseAuthParams parses the comma separated key=value pairs, the values may be quoted.
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+
+		var value string
+		if rest = strings.TrimSpace(rest); strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			value, rest = rest[1:end+1], rest[min(end+2, len(rest)):]
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+
+		params[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		s = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+
+	return params
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,82 @@
+package ip
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// startFritzBox starts a fake TR-064 service which requires the digest auth.
+func startFritzBox(t *testing.T, username, password string) string {
+	t.Helper()
+	const realm, nonce = "F!Box SOAP-Auth", "4BE4C4E9A6E1D7C5"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := parseAuthParams(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "))
+		ha1 := md5Hex(username + ":" + realm + ":" + password)
+		ha2 := md5Hex(r.Method + ":" + r.URL.RequestURI())
+		expected := md5Hex(ha1 + ":" + nonce + ":" + params["nc"] + ":" + params["cnonce"] + ":auth:" + ha2)
+		if params["username"] != username || params["response"] != expected {
+			w.Header().Set("WWW-Authenticate", `Digest realm="`+realm+`", nonce="`+nonce+`", algorithm=MD5, qop="auth"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		action := r.Header.Get("SOAPAction")
+		switch {
+		case r.URL.Path != fritzBoxControlURL:
+			http.NotFound(w, r)
+		case strings.HasSuffix(action, `#GetExternalIPAddress"`):
+			_, _ = io.WriteString(w, `<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>
+<u:GetExternalIPAddressResponse xmlns:u="urn:dslforum-org:service:WANIPConnection:1">
+<NewExternalIPAddress>203.0.113.30</NewExternalIPAddress>
+</u:GetExternalIPAddressResponse></s:Body></s:Envelope>`)
+		case strings.HasSuffix(action, `#X_AVM_DE_GetExternalIPv6Address"`):
+			_, _ = io.WriteString(w, `<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>
+<u:X_AVM_DE_GetExternalIPv6AddressResponse xmlns:u="urn:dslforum-org:service:WANIPConnection:1">
+<NewExternalIPv6Address>2001:db8::30</NewExternalIPv6Address><NewPrefixLength>64</NewPrefixLength>
+</u:X_AVM_DE_GetExternalIPv6AddressResponse></s:Body></s:Envelope>`)
+		default:
+			http.Error(w, "unknown action "+action, http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestGetIPFromFritzBox(t *testing.T) {
+	addr := startFritzBox(t, "dslf-config", "secret")
+	for ipType, expected := range map[string]string{utils.IPV4: "203.0.113.30", utils.IPV6: "2001:db8::30"} {
+		helper := &IPHelper{configuration: &settings.Settings{
+			IPType:   ipType,
+			FritzBox: settings.FritzBox{Enabled: true, Addr: addr, Username: "dslf-config", Password: "secret"},
+		}}
+
+		ip, err := helper.getIPFromFritzBox(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ip != expected {
+			t.Errorf("expected %s, got %s", expected, ip)
+		}
+	}
+}
+
+func TestGetIPFromFritzBoxWrongPassword(t *testing.T) {
+	helper := &IPHelper{configuration: &settings.Settings{
+		IPType:   utils.IPV4,
+		FritzBox: settings.FritzBox{Enabled: true, Addr: startFritzBox(t, "dslf-config", "secret"), Username: "dslf-config", Password: "wrong"},
+	}}
+
+	if _, err := helper.getIPFromFritzBox(context.Background()); err == nil {
+		t.Error("expected error for the wrong password")
+	}
+}
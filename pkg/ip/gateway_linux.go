@@ -0,0 +1,40 @@
+package ip
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+const (
+	procNetRoute        = "/proc/net/route"
+	procNetRouteDefault = "00000000"
+)
+
+// defaultGateway reads the default IPv4 gateway from the kernel routing table.
+func defaultGateway() (string, error) {
+	content, err := os.ReadFile(procNetRoute)
+	if err != nil {
+		return "", fmt.Errorf("can't detect the gateway, please configure it: %w", err)
+	}
+
+	for _, line := range strings.Split(string(content), "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != procNetRouteDefault {
+			continue
+		}
+
+		gw, err := hex.DecodeString(fields[2])
+		if err != nil || len(gw) != net.IPv4len {
+			continue
+		}
+
+		// the address is stored in host byte order
+		return net.IPv4(gw[3], gw[2], gw[1], gw[0]).String(), nil
+	}
+
+	return "", errors.New("can't detect the gateway, please configure it")
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package ip
+
+import "errors"
+
+// defaultGateway is only supported on Linux, the gateway should be configured elsewhere.
+func defaultGateway() (string, error) {
+	return "", errors.New("detecting the gateway is only supported on Linux, please configure it")
+}
@@ -0,0 +1,86 @@
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// address flags from linux/if_addr.h
+const (
+	ifaFlagTemporary  = 0x01
+	ifaFlagDADFailed  = 0x08
+	ifaFlagDeprecated = 0x20
+	ifaFlagTentative  = 0x40
+	infiniteLifetime  = 0xffffffff
+)
+
+// ifaceAddr is the interface address with its flags and preferred lifetime in seconds.
+type ifaceAddr struct {
+	addr      netip.Addr
+	flags     uint32
+	preferred uint32
+}
+
+func (a ifaceAddr) is(flag uint32) bool {
+	return a.flags&flag != 0
+}
+
+// selectInterfaceAddr picks the address with the selection policy.
+// The tentative and the failed addresses are never used and the deprecated ones go last.
+// By default the stable addresses are preferred over the temporary privacy addresses.
+func selectInterfaceAddr(addrs []ifaceAddr, sel settings.IPSelect) (netip.Addr, error) {
+	var cidr netip.Prefix
+	if sel.CIDR != "" {
+		var err error
+		if cidr, err = netip.ParsePrefix(sel.CIDR); err != nil {
+			return netip.Addr{}, err
+		}
+	}
+
+	candidates := make([]ifaceAddr, 0, len(addrs))
+	for _, a := range addrs {
+		if a.is(ifaFlagTentative) || a.is(ifaFlagDADFailed) {
+			continue
+		}
+
+		if cidr.IsValid() && !cidr.Contains(a.addr) {
+			continue
+		}
+
+		candidates = append(candidates, a)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.is(ifaFlagDeprecated) != b.is(ifaFlagDeprecated) {
+			return !a.is(ifaFlagDeprecated)
+		}
+
+		switch sel.Policy {
+		case utils.IPSelectLongestLifetime:
+			return a.preferred > b.preferred
+		case utils.IPSelectFirst:
+			return false
+		default:
+			if a.is(ifaFlagTemporary) != b.is(ifaFlagTemporary) {
+				return !a.is(ifaFlagTemporary)
+			}
+			return a.preferred > b.preferred
+		}
+	})
+
+	if len(candidates) == 0 {
+		return netip.Addr{}, errors.New("no address matches the selection")
+	}
+
+	if sel.Index >= len(candidates) {
+		return netip.Addr{}, fmt.Errorf("address index %d is out of %d matching addresses", sel.Index, len(candidates))
+	}
+
+	return candidates[sel.Index].addr, nil
+}
@@ -0,0 +1,83 @@
+//go:build linux
+
+package ip
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// IFA_FLAGS carries the full 32-bit address flags.
+const ifaFlags = 8
+
+// listInterfaceAddrs dumps the addresses of the interface with netlink RTM_GETADDR
+// to get their flags and lifetimes.
+func listInterfaceAddrs(name string) ([]ifaceAddr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := syscall.NetlinkRIB(syscall.RTM_GETADDR, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ifaceAddr
+	for _, m := range msgs {
+		if m.Header.Type == syscall.NLMSG_DONE {
+			break
+		}
+
+		if m.Header.Type != syscall.RTM_NEWADDR || len(m.Data) < syscall.SizeofIfAddrmsg {
+			continue
+		}
+
+		if int(binary.NativeEndian.Uint32(m.Data[4:8])) != iface.Index {
+			continue
+		}
+
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			return nil, err
+		}
+
+		a := ifaceAddr{flags: uint32(m.Data[2]), preferred: infiniteLifetime}
+		var address, local netip.Addr
+		for _, attr := range attrs {
+			switch attr.Attr.Type {
+			case syscall.IFA_ADDRESS:
+				address, _ = netip.AddrFromSlice(attr.Value)
+			case syscall.IFA_LOCAL:
+				local, _ = netip.AddrFromSlice(attr.Value)
+			case ifaFlags:
+				if len(attr.Value) >= 4 {
+					a.flags = binary.NativeEndian.Uint32(attr.Value)
+				}
+			case syscall.IFA_CACHEINFO:
+				if len(attr.Value) >= 4 {
+					a.preferred = binary.NativeEndian.Uint32(attr.Value)
+				}
+			}
+		}
+
+		// the local address differs from the peer address on point-to-point links
+		a.addr = address
+		if local.IsValid() {
+			a.addr = local
+		}
+
+		if a.addr.IsValid() {
+			result = append(result, a)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,31 @@
+//go:build !linux
+
+package ip
+
+import (
+	"net"
+	"net/netip"
+)
+
+// listInterfaceAddrs lists the addresses of the interface,
+// the address flags and lifetimes are only available on Linux.
+func listInterfaceAddrs(name string) ([]ifaceAddr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ifaceAddr, 0, len(addrs))
+	for _, addr := range addrs {
+		if prefix, err := netip.ParsePrefix(addr.String()); err == nil {
+			result = append(result, ifaceAddr{addr: prefix.Addr(), preferred: infiniteLifetime})
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,46 @@
+package ip
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+func TestSelectInterfaceAddr(t *testing.T) {
+	addrs := []ifaceAddr{
+		{addr: netip.MustParseAddr("2001:db8::aaaa"), flags: ifaFlagTemporary, preferred: 80000},
+		{addr: netip.MustParseAddr("2001:db8::dead"), flags: ifaFlagTentative, preferred: infiniteLifetime},
+		{addr: netip.MustParseAddr("2001:db8::bbbb"), flags: ifaFlagDeprecated, preferred: 0},
+		{addr: netip.MustParseAddr("2001:db8::1"), preferred: 3600},
+		{addr: netip.MustParseAddr("2001:db8:1::1"), preferred: 7200},
+	}
+
+	tests := []struct {
+		sel  settings.IPSelect
+		addr string
+	}{
+		{settings.IPSelect{}, "2001:db8:1::1"},
+		{settings.IPSelect{Policy: utils.IPSelectStable, Index: 1}, "2001:db8::1"},
+		{settings.IPSelect{Policy: utils.IPSelectLongestLifetime}, "2001:db8::aaaa"},
+		{settings.IPSelect{Policy: utils.IPSelectFirst}, "2001:db8::aaaa"},
+		{settings.IPSelect{CIDR: "2001:db8::/64"}, "2001:db8::1"},
+		{settings.IPSelect{CIDR: "2001:db8::/64", Index: 2}, "2001:db8::bbbb"},
+		{settings.IPSelect{CIDR: "2001:db8::/64", Index: 3}, ""},
+	}
+
+	for _, tt := range tests {
+		addr, err := selectInterfaceAddr(addrs, tt.sel)
+		if tt.addr == "" {
+			if err == nil {
+				t.Errorf("%+v: expected error, got %s", tt.sel, addr)
+			}
+			continue
+		}
+
+		if err != nil || addr.String() != tt.addr {
+			t.Errorf("%+v: expected %s, got %s (%v)", tt.sel, tt.addr, addr, err)
+		}
+	}
+}
@@ -2,21 +2,15 @@ package ip
 
 import (
 	"context"
-	"crypto/tls"
-	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
-	"net/url"
-	"path"
-	"regexp"
+	"net/netip"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/pchchv/goddns/internal/settings"
@@ -27,20 +21,44 @@ import (
 var (
 	helperInstance *IPHelper
 	helperOnce     sync.Once
+	wanHelpers     = map[string]*IPHelper{}
+	wanMutex       sync.Mutex
 )
 
 type IPHelper struct {
-	reqURLs       []string
+	reqURLs       []settings.IPURL
 	currentIP     string
+	result        IPResult
 	mutex         sync.RWMutex
 	configuration *settings.Settings
 	idx           int64
+	wan           string
+	bind          settings.Bind
+	wanSources    []settings.IPSource
+	failedSince   time.Time
+	health        map[string]*sourceHealth
+	healthMutex   sync.Mutex
+	cgnat         bool
+	neighbors     map[netip.Addr]*neighborAddr
+	neighborMutex sync.Mutex
 }
 
 func (helper *IPHelper) UpdateConfiguration(conf *settings.Settings) {
 	helper.mutex.Lock()
 	defer helper.mutex.Unlock()
 
+	helper.configuration = conf
+	helper.bind = conf.Bind
+	helper.wanSources = nil
+	if helper.wan != "" {
+		if wan, ok := conf.GetWAN(helper.wan); ok {
+			helper.bind = wan.Bind
+			helper.wanSources = wan.Sources
+		} else {
+			log.Printf("WAN source %s is not configured, using the default binding", helper.wan)
+		}
+	}
+
 	// clear urls
 	helper.reqURLs = helper.reqURLs[:0]
 	// reset the index
@@ -49,24 +67,24 @@ func (helper *IPHelper) UpdateConfiguration(conf *settings.Settings) {
 	if conf.IPType == "" || strings.ToUpper(conf.IPType) == utils.IPV4 {
 		// filter empty urls
 		for _, url := range conf.IPUrls {
-			if url != "" {
+			if url.URL != "" {
 				helper.reqURLs = append(helper.reqURLs, url)
 			}
 		}
 
 		if conf.IPUrl != "" {
-			helper.reqURLs = append(helper.reqURLs, conf.IPUrl)
+			helper.reqURLs = append(helper.reqURLs, settings.IPURL{URL: conf.IPUrl})
 		}
 	} else {
 		// filter empty urls
 		for _, url := range conf.IPV6Urls {
-			if url != "" {
+			if url.URL != "" {
 				helper.reqURLs = append(helper.reqURLs, url)
 			}
 		}
 
 		if conf.IPV6Url != "" {
-			helper.reqURLs = append(helper.reqURLs, conf.IPV6Url)
+			helper.reqURLs = append(helper.reqURLs, settings.IPURL{URL: conf.IPV6Url})
 		}
 	}
 
@@ -85,241 +103,282 @@ func (helper *IPHelper) GetCurrentIP() string {
 	return helper.currentIP
 }
 
+// FailedSince returns the time the IP detection fails since,
+// the zero time if the last detection succeeded.
+func (helper *IPHelper) FailedSince() time.Time {
+	helper.mutex.RLock()
+	defer helper.mutex.RUnlock()
+
+	return helper.failedSince
+}
+
+// GetIPResult returns the current IP with the source it was detected by.
+func (helper *IPHelper) GetIPResult() (IPResult, bool) {
+	helper.mutex.RLock()
+	defer helper.mutex.RUnlock()
+
+	return helper.result, helper.result.Addr.IsValid()
+}
+
+// NewIPHelper returns the helper which detects the IP on demand, without the periodic refresh.
+func NewIPHelper(conf *settings.Settings) *IPHelper {
+	helper := &IPHelper{idx: -1}
+	helper.UpdateConfiguration(conf)
+	return helper
+}
+
 func GetIPHelperInstance(conf *settings.Settings) *IPHelper {
 	helperOnce.Do(func() {
 		helperInstance = &IPHelper{
 			configuration: conf,
 			idx:           -1,
+			bind:          conf.Bind,
 		}
 
-		safe.SafeGo(func() {
-			for {
-				helperInstance.getCurrentIP()
-				time.Sleep(time.Second * time.Duration(conf.Interval))
-			}
-		})
+		helperInstance.start()
 	})
 
 	return helperInstance
 }
 
-func (helper *IPHelper) getIPFromMikrotik() string {
-	u, err := url.Parse(helper.configuration.Mikrotik.Addr)
-	if err != nil {
-		log.Fatal("fail to parse mikrotik address: ", err)
-		return ""
+// GetWANIPHelper returns the IP helper which detects the IP
+// through the named WAN source, the default helper is returned for the empty name.
+func GetWANIPHelper(conf *settings.Settings, name string) *IPHelper {
+	if name == "" {
+		return GetIPHelperInstance(conf)
 	}
 
-	u.Path = path.Join(u.Path, "/rest/ip/address")
-	q := u.Query()
-	q.Add("interface", helper.configuration.Mikrotik.Interface)
-	q.Add(".proplist", "address")
-	u.RawQuery = q.Encode()
+	wanMutex.Lock()
+	defer wanMutex.Unlock()
 
-	req, _ := http.NewRequest("GET", u.String(), nil)
-	auth := fmt.Sprintf("%s:%s", helper.configuration.Mikrotik.Username, helper.configuration.Mikrotik.Password)
-	req.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
-	req.Header.Add("Content-Type", "application/json")
+	if helper, ok := wanHelpers[name]; ok {
+		return helper
+	}
 
-	client := &http.Client{
-		Timeout:   time.Second * utils.DefaultTimeout,
-		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	helper := &IPHelper{
+		idx: -1,
+		wan: name,
 	}
+	helper.UpdateConfiguration(conf)
+	helper.start()
+	wanHelpers[name] = helper
 
-	response, err := client.Do(req)
+	return helper
+}
+
+// start refreshes the current IP periodically.
+func (helper *IPHelper) start() {
+	safe.SafeGo(func() {
+		for {
+			helper.getCurrentIP()
+
+			helper.mutex.RLock()
+			interval := helper.configuration.Interval
+			helper.mutex.RUnlock()
+
+			time.Sleep(time.Second * time.Duration(interval))
+		}
+	})
+}
+
+// getIPOnline gets public IP from internet.
+func (helper *IPHelper) getIPOnline(ctx context.Context) (string, error) {
+	client, err := helper.newOnlineClient()
 	if err != nil {
-		log.Fatal("request mikrotik address failed:", err)
-		return ""
+		return "", fmt.Errorf("cannot create HTTP transport: %w", err)
 	}
-	defer response.Body.Close()
 
-	if response.StatusCode != http.StatusOK {
-		log.Fatal("request code failed: ", response.StatusCode)
-		return ""
+	sources := helper.orderedSources()
+	if helper.configuration.IPQuorum.Enabled {
+		if ip := helper.getIPByQuorum(ctx, client, sources); ip != "" {
+			return ip, nil
+		}
+		return "", errors.New("IP quorum is not reached")
 	}
 
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		log.Fatal("read body failed: ", err)
-		return ""
-	}
+	for _, source := range sources {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 
-	m := []map[string]string{}
-	if err := json.Unmarshal(body, &m); err != nil {
-		log.Fatal("unmarshal body failed: ", err)
-		return ""
-	} else if len(m) < 1 {
-		log.Fatal("could not get ip from response: ", m)
-		return ""
+		onlineIP, err := helper.querySource(ctx, client, source)
+		if err != nil {
+			log.Println("Cannot get IP:", err)
+			continue
+		}
+
+		return onlineIP, nil
 	}
 
-	res := strings.Split(m[0]["address"], "/")
-	return res[0]
+	return "", errors.New("all the online sources failed")
 }
 
-func (helper *IPHelper) getNext() string {
-	newIdx := atomic.AddInt64(&helper.idx, 1)
-	helper.mutex.RLock()
-	defer helper.mutex.RUnlock()
-	newIdx %= int64(len(helper.reqURLs))
-	return helper.reqURLs[newIdx]
-}
+func (helper *IPHelper) newOnlineClient() (*http.Client, error) {
+	transport, err := utils.NewTransport(helper.configuration, utils.ComponentIPDetection)
+	if err != nil {
+		return nil, err
+	}
 
-// getIPOnline gets public IP from internet.
-func (helper *IPHelper) getIPOnline() (onlineIP string) {
-	transport := &http.Transport{
-		DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+	// the address family and the source binding
+	// can only be forced when connecting directly
+	if !utils.UseProxyFor(helper.configuration, utils.ComponentIPDetection) {
+		transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
 			proto := "tcp"
 			if strings.ToUpper(helper.configuration.IPType) == utils.IPV4 {
 				// Force the network to "tcp4" to use only IPv4
 				proto = "tcp4"
 			}
 
-			return (&net.Dialer{
-				Timeout:   time.Second * utils.DefaultTimeout,
-				KeepAlive: 30 * time.Second,
-			}).DialContext(ctx, proto, addr)
-		},
+			dialer, err := utils.NewDialer(helper.bind, proto)
+			if err != nil {
+				return nil, err
+			}
+
+			return dialer.DialContext(ctx, proto, addr)
+		}
 	}
-	client := &http.Client{
+
+	return &http.Client{
 		Timeout:   time.Second * utils.DefaultTimeout,
 		Transport: transport,
+	}, nil
+}
+
+// querySource gets public IP from the IP source and updates its statistics.
+func (helper *IPHelper) querySource(ctx context.Context, client *http.Client, source settings.IPURL) (string, error) {
+	start := time.Now()
+	var onlineIP string
+	var err error
+	if isDNSSource(source.URL) {
+		onlineIP, err = helper.getIPFromDNS(source.URL)
+	} else {
+		onlineIP, err = helper.getIPFromURL(ctx, client, source)
 	}
-	for {
-		reqURL := helper.getNext()
-		req, _ := http.NewRequest("GET", reqURL, nil)
-		if helper.configuration.UserAgent != "" {
-			req.Header.Set("User-Agent", helper.configuration.UserAgent)
-		}
 
-		response, err := client.Do(req)
-		if err != nil {
-			log.Fatal("Cannot get IP:", err)
-			time.Sleep(time.Millisecond * 300)
-			continue
-		}
+	if err == nil {
+		err = helper.checkIP(onlineIP)
+	}
 
-		if response.StatusCode != http.StatusOK {
-			log.Fatal(fmt.Sprintf("request %v got httpCode:%v", reqURL, response.StatusCode))
-			continue
-		}
+	if err != nil {
+		helper.recordFailure(source.URL)
+		return "", err
+	}
 
-		body, _ := io.ReadAll(response.Body)
-		ipReg := regexp.MustCompile(utils.IPPattern)
-		onlineIP = ipReg.FindString(string(body))
-		if onlineIP == "" {
-			log.Fatal(fmt.Sprintf("request:%v failed to get online IP", reqURL))
-			continue
-		}
+	helper.recordSuccess(source.URL, time.Since(start))
+	return onlineIP, nil
+}
 
-		if isIPv4(onlineIP) && strings.ToUpper(helper.configuration.IPType) != utils.IPV4 {
-			log.Fatalf("The online IP (%s) from %s is not IPV6, will skip it.", onlineIP, reqURL)
-			continue
-		} else if strings.ToUpper(helper.configuration.IPType) != utils.IPV6 {
-			log.Fatalf("The online IP (%s) from %s is not IPV4, will skip it.", onlineIP, reqURL)
-			continue
-		}
+func (helper *IPHelper) getIPFromURL(ctx context.Context, client *http.Client, source settings.IPURL) (string, error) {
+	reqURL := source.URL
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return "", err
+	}
 
-		log.Printf("Get ip success by: %s, online IP: %s", reqURL, onlineIP)
+	if helper.configuration.UserAgent != "" {
+		req.Header.Set("User-Agent", helper.configuration.UserAgent)
+	}
 
-		if err = response.Body.Close(); err != nil {
-			log.Fatal(fmt.Sprintf("request:%v failed to get online IP", reqURL))
-			continue
-		}
+	response, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
 
-		if onlineIP == "" {
-			log.Fatal("fail to get online IP")
-		}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request %v got httpCode:%v", reqURL, response.StatusCode)
+	}
 
-		break
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err
 	}
 
-	return
+	ip, err := extractIP(source, response, body)
+	if err != nil {
+		return "", fmt.Errorf("request:%v failed to get online IP: %w", reqURL, err)
+	}
+
+	addr, err := parseIP(ip, strings.ToUpper(helper.configuration.IPType) == utils.IPV6)
+	if err != nil {
+		return "", fmt.Errorf("request:%v returned invalid online IP: %w", reqURL, err)
+	}
+
+	log.Printf("Get ip success by: %s, online IP: %s", reqURL, addr.String())
+	return addr.String(), nil
+}
+
+// ipInterface returns the interface the IP is read from, the interface of the WAN for the WAN helpers.
+func (helper *IPHelper) ipInterface() string {
+	if helper.wan != "" {
+		return helper.bind.Interface
+	}
+
+	return helper.configuration.IPInterface
 }
 
 // getIPFromInterface gets IP address from the specific interface.
-func (helper *IPHelper) getIPFromInterface() (string, error) {
-	ifaces, err := net.InterfaceByName(helper.configuration.IPInterface)
-	if err != nil {
-		log.Fatal("Can't get network device "+helper.configuration.IPInterface+":", err)
-		return "", err
+func (helper *IPHelper) getIPFromInterface(context.Context) (string, error) {
+	iface := helper.ipInterface()
+	if iface == "" {
+		return "", errors.New("no IP interface is configured")
 	}
 
-	addrs, err := ifaces.Addrs()
+	addrs, err := listInterfaceAddrs(iface)
 	if err != nil {
-		log.Fatal("Can't get address from "+helper.configuration.IPInterface+":", err)
+		log.Println("Can't get address from "+iface+":", err)
 		return "", err
 	}
 
-	for _, addr := range addrs {
-		var ip net.IP
-		switch v := addr.(type) {
-		case *net.IPNet:
-			ip = v.IP
-		case *net.IPAddr:
-			ip = v.IP
-		}
-
-		if ip == nil {
+	ipv6 := strings.ToUpper(helper.configuration.IPType) == utils.IPV6
+	candidates := make([]ifaceAddr, 0, len(addrs))
+	for _, a := range addrs {
+		a.addr = a.addr.Unmap()
+		if a.addr.IsPrivate() || !a.addr.IsGlobalUnicast() || a.addr.Is6() != ipv6 {
 			continue
 		}
 
-		if ip.IsPrivate() {
+		if err := helper.checkIP(a.addr.String()); err != nil {
+			log.Println("Skip the interface address:", err)
 			continue
 		}
 
-		if isIPv4(ip.String()) && strings.ToUpper(helper.configuration.IPType) != utils.IPV4 {
-			continue
-		} else if strings.ToUpper(helper.configuration.IPType) != utils.IPV6 {
-			continue
-		}
+		candidates = append(candidates, a)
+	}
 
-		if ip.String() != "" {
-			log.Printf("Get ip success from network interface by: %s, IP: %s", helper.configuration.IPInterface, ip.String())
-			return ip.String(), nil
-		}
+	addr, err := selectInterfaceAddr(candidates, helper.configuration.IPSelect)
+	if err != nil {
+		return "", fmt.Errorf("can't get a valid address from %s: %w", iface, err)
 	}
 
-	return "", errors.New("can't get a valid address from " + helper.configuration.IPInterface)
+	log.Printf("Get ip success from network interface by: %s, IP: %s", iface, addr.String())
+	return addr.String(), nil
 }
 
-// getCurrentIP gets an IP from either internet or specific interface, depending on configuration.
+// getCurrentIP gets an IP from the sources of the detection pipeline.
 func (helper *IPHelper) getCurrentIP() {
-	var err error
-	var ip string
-	if helper.configuration.Mikrotik.Enabled {
-		if ip = helper.getIPFromMikrotik(); ip == "" {
-			log.Fatal("get ip from mikrotik failed. Fallback to get ip from onlinke if possible.")
-		} else {
-			helper.setCurrentIP(ip)
-			return
-		}
-	}
-
-	if len(helper.reqURLs) > 0 {
-		if ip = helper.getIPOnline(); ip == "" {
-			log.Fatal("get ip online failed. Fallback to get ip from interface if possible.")
-		} else {
-			helper.setCurrentIP(ip)
-			return
+	result, err := helper.detectIP()
+	if err != nil {
+		log.Println("fail to detect IP:", err)
+		helper.mutex.Lock()
+		if helper.failedSince.IsZero() {
+			helper.failedSince = time.Now()
 		}
+		helper.mutex.Unlock()
+		return
 	}
 
-	if helper.configuration.IPInterface != "" {
-		if ip, err = helper.getIPFromInterface(); err != nil {
-			log.Fatal("get ip from interface failed. There is no more ways to try.")
-		} else {
-			helper.setCurrentIP(ip)
-			return
-		}
-	}
+	helper.checkCGNAT(result.Addr.String())
+	helper.setCurrentIP(result)
 }
 
-func (helper *IPHelper) setCurrentIP(ip string) {
+func (helper *IPHelper) setCurrentIP(result IPResult) {
 	helper.mutex.Lock()
 	defer helper.mutex.Unlock()
 
-	helper.currentIP = ip
+	helper.currentIP = result.Addr.String()
+	helper.result = result
+	helper.failedSince = time.Time{}
 }
 
 func isIPv4(ip string) bool {
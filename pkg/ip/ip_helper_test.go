@@ -28,7 +28,7 @@ func TestGetMikrotikIP(t *testing.T) {
 
 func TestGetCurrentIP(t *testing.T) {
 	t.Skip()
-	conf := &settings.Settings{IPUrls: []string{"https://myip.biturl.top"}}
+	conf := &settings.Settings{IPUrls: []settings.IPURL{{URL: "https://myip.biturl.top"}}}
 	helper := ip.GetIPHelperInstance(conf)
 	if ip := helper.GetCurrentIP(); ip == "" {
 		t.Log("IP is empty...")
@@ -0,0 +1,217 @@
+package ip
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+const (
+	routerOSAPIPort    = "8728"
+	routerOSAPISSLPort = "8729"
+)
+
+// getIPFromMikrotik gets IP from the RouterOS device with the REST or the binary API.
+func (helper *IPHelper) getIPFromMikrotik(ctx context.Context) (string, error) {
+	conf := helper.configuration.Mikrotik
+	u, err := url.Parse(conf.Addr)
+	if err != nil {
+		return "", fmt.Errorf("fail to parse mikrotik address: %w", err)
+	}
+
+	tlsConfig, err := mikrotikTLSConfig(helper.configuration, u.Hostname())
+	if err != nil {
+		return "", err
+	}
+
+	ipv6 := strings.ToUpper(helper.configuration.IPType) == utils.IPV6
+	menu, props := mikrotikMenu(conf.Source, ipv6)
+
+	var records []map[string]string
+	switch u.Scheme {
+	case "api", "api-ssl":
+		records, err = queryRouterOSAPI(ctx, u, tlsConfig, conf, menu, props)
+	default:
+		records, err = queryMikrotikREST(ctx, u, tlsConfig, conf, menu, props)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	ip, err := helper.selectMikrotikAddress(records, ipv6)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("Get ip success from mikrotik: %s, IP: %s", u.Host, ip)
+	return ip, nil
+}
+
+// mikrotikMenu returns the menu to read the address from and its properties.
+func mikrotikMenu(source string, ipv6 bool) (string, []string) {
+	if source == utils.MikrotikSourceCloud {
+		return "/ip/cloud", []string{"public-address", "public-address-ipv6"}
+	}
+
+	menu := "/ip/address"
+	if ipv6 {
+		menu = "/ipv6/address"
+	}
+
+	return menu, []string{"address", "dynamic", "disabled", "invalid"}
+}
+
+// selectMikrotikAddress returns the first enabled and valid address of the expected family.
+func (helper *IPHelper) selectMikrotikAddress(records []map[string]string, ipv6 bool) (string, error) {
+	conf := helper.configuration.Mikrotik
+	for _, r := range records {
+		address := r["address"]
+		if conf.Source == utils.MikrotikSourceCloud {
+			address = r["public-address"]
+			if ipv6 {
+				address = r["public-address-ipv6"]
+			}
+		}
+
+		if r["disabled"] == "true" || r["invalid"] == "true" || (conf.ExcludeDynamic && r["dynamic"] == "true") {
+			continue
+		}
+
+		address, _, _ = strings.Cut(address, "/")
+		addr, err := parseIP(address, ipv6)
+		if err != nil || !addr.IsGlobalUnicast() {
+			continue
+		}
+
+		return addr.String(), nil
+	}
+
+	return "", errors.New("mikrotik has no valid address of the IP type")
+}
+
+// mikrotikTLSConfig verifies the device certificate unless the verification is skipped,
+// the CA of the device is used in place of the CA bundle of the TLS settings.
+func mikrotikTLSConfig(conf *settings.Settings, serverName string) (*tls.Config, error) {
+	device := *conf
+	device.SkipSSLVerify = conf.SkipSSLVerify || conf.Mikrotik.SkipVerify
+	if conf.Mikrotik.CAFile != "" {
+		device.TLS.CAFile = conf.Mikrotik.CAFile
+	}
+
+	tlsConfig, err := utils.GetTLSConfig(&device)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig.ServerName = serverName
+	return tlsConfig, nil
+}
+
+func queryMikrotikREST(ctx context.Context, u *url.URL, tlsConfig *tls.Config, conf settings.Mikrotik, menu string, props []string) ([]map[string]string, error) {
+	reqURL := *u
+	reqURL.Path = path.Join(u.Path, "/rest", menu)
+	q := reqURL.Query()
+	if conf.Source != utils.MikrotikSourceCloud {
+		q.Add("interface", conf.Interface)
+	}
+	q.Add(".proplist", strings.Join(props, ","))
+	reqURL.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(conf.Username, conf.Password)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{
+		Timeout:   time.Second * utils.DefaultTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request mikrotik address failed: %w", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request mikrotik address got httpCode:%d, body: %s", response.StatusCode, string(body))
+	}
+
+	// the menus with a single item like /ip/cloud return an object
+	if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '{' {
+		body = append(append([]byte{'['}, body...), ']')
+	}
+
+	var records []map[string]string
+	if err = json.Unmarshal(body, &records); err != nil {
+		return nil, fmt.Errorf("unmarshal mikrotik response failed: %w", err)
+	}
+
+	return records, nil
+}
+
+func queryRouterOSAPI(ctx context.Context, u *url.URL, tlsConfig *tls.Config, conf settings.Mikrotik, menu string, props []string) ([]map[string]string, error) {
+	host := u.Host
+	if u.Port() == "" {
+		port := routerOSAPIPort
+		if u.Scheme == "api-ssl" {
+			port = routerOSAPISSLPort
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	dialer := &net.Dialer{Timeout: time.Second * utils.DefaultTimeout}
+	var conn net.Conn
+	var err error
+	if u.Scheme == "api-ssl" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", host)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("connect to mikrotik API failed: %w", err)
+	}
+
+	// the connection is closed to interrupt the session once the context is canceled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c := newRouterOSConn(conn)
+	defer c.Close()
+
+	if err = c.login(conf.Username, conf.Password); err != nil {
+		return nil, err
+	}
+
+	words := []string{menu + "/print", "=.proplist=" + strings.Join(props, ",")}
+	if conf.Source != utils.MikrotikSourceCloud {
+		words = append(words, "?interface="+conf.Interface)
+	}
+
+	records, _, err := c.run(words...)
+	return records, err
+}
@@ -0,0 +1,107 @@
+package ip
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// startRouterOSAPI starts a fake RouterOS binary API which replies to the address print.
+func startRouterOSAPI(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		c := newRouterOSConn(conn)
+		defer c.Close()
+		for {
+			sentence, err := c.readSentence()
+			if err != nil {
+				return
+			}
+
+			switch sentence[0] {
+			case "/login":
+				_ = c.writeSentence([]string{"!done"})
+			case "/ip/address/print":
+				_ = c.writeSentence([]string{"!re", "=address=198.51.100.1/24", "=dynamic=false", "=disabled=true"})
+				_ = c.writeSentence([]string{"!re", "=address=192.0.2.1/32", "=dynamic=true", "=disabled=false"})
+				_ = c.writeSentence([]string{"!re", "=address=203.0.113.10/24", "=dynamic=false", "=disabled=false"})
+				_ = c.writeSentence([]string{"!done"})
+			default:
+				_ = c.writeSentence([]string{"!trap", "=message=no such command"})
+				_ = c.writeSentence([]string{"!done"})
+			}
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestGetIPFromMikrotikAPI(t *testing.T) {
+	addr := startRouterOSAPI(t)
+	helper := &IPHelper{configuration: &settings.Settings{
+		IPType: utils.IPV4,
+		Mikrotik: settings.Mikrotik{
+			Enabled:        true,
+			Addr:           "api://" + addr,
+			Interface:      "pppoe-out",
+			ExcludeDynamic: true,
+		},
+	}}
+
+	ip, err := helper.getIPFromMikrotik(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ip != "203.0.113.10" {
+		t.Errorf("expected 203.0.113.10, got %s", ip)
+	}
+}
+
+func TestGetIPFromMikrotikREST(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/ipv6/address" || r.URL.Query().Get("interface") != "pppoe-out" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = io.WriteString(w, `[{"address":"fe80::1/64","dynamic":"true","disabled":"false"},`+
+			`{"address":"2001:db8::1/64","dynamic":"true","disabled":"false"}]`)
+	}))
+	t.Cleanup(server.Close)
+
+	helper := &IPHelper{configuration: &settings.Settings{
+		IPType: utils.IPV6,
+		Mikrotik: settings.Mikrotik{
+			Enabled:   true,
+			Addr:      server.URL,
+			Interface: "pppoe-out",
+		},
+	}}
+
+	ip, err := helper.getIPFromMikrotik(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ip != "2001:db8::1" {
+		t.Errorf("expected 2001:db8::1, got %s", ip)
+	}
+}
@@ -0,0 +1,199 @@
+package ip
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// NAT-PMP (RFC 6886) and PCP (RFC 6887) constants.
+const (
+	natPMPPort         = "5351"
+	natPMPVersion      = 0
+	natPMPOpExternalIP = 0
+	natPMPResponseFlag = 0x80
+	natPMPInitialWait  = 250 * time.Millisecond
+	natPMPMaxAttempts  = 4
+	pcpVersion         = 2
+	pcpOpMap           = 1
+	pcpRequestLength   = 60
+	pcpMappingLifetime = 120 // in seconds
+	pcpProtocolUDP     = 17
+)
+
+// getIPFromNATPMP gets WAN IP from the gateway with NAT-PMP,
+// or with PCP if it is enabled.
+func (helper *IPHelper) getIPFromNATPMP(ctx context.Context) (string, error) {
+	ipv6 := strings.ToUpper(helper.configuration.IPType) == utils.IPV6
+	if ipv6 && !helper.configuration.NATPMP.PCP {
+		return "", errors.New("NAT-PMP only reports IPv4 addresses, enable PCP for IPv6")
+	}
+
+	gateway, err := helper.natPMPGateway()
+	if err != nil {
+		return "", err
+	}
+
+	dialer, err := utils.NewDialer(helper.bind, "udp")
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := dialer.DialContext(ctx, "udp", gateway)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var ip net.IP
+	if helper.configuration.NATPMP.PCP {
+		ip, err = queryPCP(conn)
+	} else {
+		ip, err = queryNATPMP(conn)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if (ip.To4() == nil) != ipv6 {
+		return "", errors.New("gateway returned an address of the wrong family: " + ip.String())
+	}
+
+	log.Printf("Get ip success from gateway: %s, IP: %s", gateway, ip.String())
+	return ip.String(), nil
+}
+
+// natPMPGateway returns the gateway address with port,
+// the default IPv4 gateway is used if it is not configured.
+func (helper *IPHelper) natPMPGateway() (string, error) {
+	gateway := helper.configuration.NATPMP.Gateway
+	if gateway == "" {
+		var err error
+		if gateway, err = defaultGateway(); err != nil {
+			return "", err
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(gateway); err != nil {
+		gateway = net.JoinHostPort(gateway, natPMPPort)
+	}
+
+	return gateway, nil
+}
+
+// exchangeGateway sends the request and waits for the response,
+// the request is retransmitted with doubling timeouts.
+func exchangeGateway(conn net.Conn, req []byte, valid func([]byte) bool) ([]byte, error) {
+	buf := make([]byte, 1100)
+	wait := natPMPInitialWait
+	for attempt := 0; attempt < natPMPMaxAttempts; attempt++ {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+
+		if err := conn.SetReadDeadline(time.Now().Add(wait)); err != nil {
+			return nil, err
+		}
+
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				return nil, err
+			}
+
+			if valid(buf[:n]) {
+				return buf[:n], nil
+			}
+		}
+
+		wait *= 2
+	}
+
+	return nil, errors.New("gateway did not respond")
+}
+
+// queryNATPMP sends the external address request.
+func queryNATPMP(conn net.Conn) (net.IP, error) {
+	resp, err := exchangeGateway(conn, []byte{natPMPVersion, natPMPOpExternalIP}, func(b []byte) bool {
+		return len(b) >= 12 && b[0] == natPMPVersion && b[1] == natPMPResponseFlag|natPMPOpExternalIP
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if code := binary.BigEndian.Uint16(resp[2:4]); code != 0 {
+		return nil, fmt.Errorf("NAT-PMP request failed with result code %d", code)
+	}
+
+	return net.IPv4(resp[8], resp[9], resp[10], resp[11]), nil
+}
+
+// queryPCP requests a short-lived mapping of the local port
+// to learn the assigned external address and deletes it afterwards.
+func queryPCP(conn net.Conn) (net.IP, error) {
+	local := conn.LocalAddr().(*net.UDPAddr)
+	var nonce [12]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+
+	resp, err := exchangeGateway(conn, newPCPMapRequest(local, nonce, pcpMappingLifetime), func(b []byte) bool {
+		return len(b) >= pcpRequestLength && b[0] == pcpVersion && b[1] == natPMPResponseFlag|pcpOpMap &&
+			bytes.Equal(b[24:36], nonce[:])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if code := resp[3]; code != 0 {
+		return nil, fmt.Errorf("PCP request failed with result code %d", code)
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, resp[44:60])
+
+	// the mapping is not needed anymore
+	if _, err = conn.Write(newPCPMapRequest(local, nonce, 0)); err != nil {
+		log.Println("Failed to delete the PCP mapping:", err)
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4, nil
+	}
+
+	return ip, nil
+}
+
+func newPCPMapRequest(local *net.UDPAddr, nonce [12]byte, lifetime uint32) []byte {
+	req := make([]byte, pcpRequestLength)
+	req[0] = pcpVersion
+	req[1] = pcpOpMap
+	binary.BigEndian.PutUint32(req[4:8], lifetime)
+	copy(req[8:24], local.IP.To16())
+	copy(req[24:36], nonce[:])
+	req[36] = pcpProtocolUDP
+	binary.BigEndian.PutUint16(req[40:42], uint16(local.Port))
+	if local.IP.To4() != nil {
+		// suggest any IPv4 external address
+		copy(req[44:60], net.IPv4zero.To16())
+	}
+
+	return req
+}
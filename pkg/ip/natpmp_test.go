@@ -0,0 +1,82 @@
+package ip
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// startNATPMPGateway starts a fake gateway answering NAT-PMP and PCP requests with ip.
+func startNATPMPGateway(t *testing.T, ip net.IP) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1100)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+
+			var resp []byte
+			switch {
+			case n == 2 && buf[0] == natPMPVersion && buf[1] == natPMPOpExternalIP:
+				resp = make([]byte, 12)
+				resp[1] = natPMPResponseFlag | natPMPOpExternalIP
+				copy(resp[8:12], ip.To4())
+			case n == pcpRequestLength && buf[0] == pcpVersion && buf[1] == pcpOpMap:
+				resp = make([]byte, pcpRequestLength)
+				copy(resp, buf[:n])
+				resp[1] = natPMPResponseFlag | pcpOpMap
+				binary.BigEndian.PutUint32(resp[8:12], 0)
+				copy(resp[44:60], ip.To16())
+			default:
+				continue
+			}
+
+			_, _ = conn.WriteToUDP(resp, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestGetIPFromNATPMP(t *testing.T) {
+	tests := []struct {
+		name   string
+		ipType string
+		pcp    bool
+		ip     string
+	}{
+		{"NAT-PMP", utils.IPV4, false, "203.0.113.20"},
+		{"PCP IPv4", utils.IPV4, true, "203.0.113.21"},
+		{"PCP IPv6", utils.IPV6, true, "2001:db8::22"},
+	}
+
+	for _, tt := range tests {
+		gateway := startNATPMPGateway(t, net.ParseIP(tt.ip))
+		helper := &IPHelper{configuration: &settings.Settings{
+			IPType: tt.ipType,
+			NATPMP: settings.NATPMP{Enabled: true, Gateway: gateway, PCP: tt.pcp},
+		}}
+
+		ip, err := helper.getIPFromNATPMP(context.Background())
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+
+		if ip != tt.ip {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.ip, ip)
+		}
+	}
+}
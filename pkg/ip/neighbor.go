@@ -0,0 +1,119 @@
+package ip
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// defaultNeighborGrace is how long the address of the device is kept
+// after it drops out of the neighbor table.
+const defaultNeighborGrace = 10 * time.Minute
+
+type neighbor struct {
+	addr netip.Addr
+	mac  net.HardwareAddr
+}
+
+// neighborAddr keeps the history of the neighbor address.
+type neighborAddr struct {
+	mac       string
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// GetNeighborIP returns the global IPv6 address in the prefix
+// of the LAN device with the MAC from the kernel neighbor table.
+func (helper *IPHelper) GetNeighborIP(mac string, prefix netip.Prefix) (netip.Addr, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	entries, err := listNeighbors()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	return helper.selectNeighbor(entries, hw, prefix, time.Now())
+}
+
+// selectNeighbor picks the address of the device. The address built with EUI-64 is preferred,
+// then the address seen for the longest time, as the temporary privacy addresses are rotated.
+// The addresses of the device which dropped out of the table are used until the grace period expires.
+func (helper *IPHelper) selectNeighbor(entries []neighbor, hw net.HardwareAddr, prefix netip.Prefix, now time.Time) (netip.Addr, error) {
+	helper.neighborMutex.Lock()
+	defer helper.neighborMutex.Unlock()
+
+	if helper.neighbors == nil {
+		helper.neighbors = map[netip.Addr]*neighborAddr{}
+	}
+
+	mac := hw.String()
+	for _, e := range entries {
+		if !bytes.Equal(e.mac, hw) || !isGlobalIPv6(e.addr) {
+			continue
+		}
+
+		seen, ok := helper.neighbors[e.addr]
+		if !ok || seen.mac != mac {
+			seen = &neighborAddr{mac: mac, firstSeen: now}
+			helper.neighbors[e.addr] = seen
+		}
+		seen.lastSeen = now
+	}
+
+	grace := defaultNeighborGrace
+	if seconds := helper.configuration.Prefix.NeighborGrace; seconds > 0 {
+		grace = time.Duration(seconds) * time.Second
+	}
+
+	var eui [8]byte
+	if len(hw) == 6 {
+		eui, _ = eui64(mac)
+	}
+
+	var best netip.Addr
+	var bestSeen *neighborAddr
+	for addr, seen := range helper.neighbors {
+		if now.Sub(seen.lastSeen) > grace {
+			delete(helper.neighbors, addr)
+			continue
+		}
+
+		if seen.mac != mac || !prefix.Contains(addr) {
+			continue
+		}
+
+		if bestSeen == nil || preferNeighbor(addr, seen, best, bestSeen, eui) {
+			best, bestSeen = addr, seen
+		}
+	}
+
+	if bestSeen == nil {
+		return netip.Addr{}, errors.New("device " + mac + " has no address in " + prefix.String() + " in the neighbor table")
+	}
+
+	return best, nil
+}
+
+func preferNeighbor(addr netip.Addr, seen *neighborAddr, best netip.Addr, bestSeen *neighborAddr, eui [8]byte) bool {
+	a, b := addr.As16(), best.As16()
+	if isEUI, bestIsEUI := bytes.Equal(a[8:], eui[:]), bytes.Equal(b[8:], eui[:]); isEUI != bestIsEUI {
+		return isEUI
+	}
+
+	if !seen.firstSeen.Equal(bestSeen.firstSeen) {
+		return seen.firstSeen.Before(bestSeen.firstSeen)
+	}
+
+	return addr.Less(best)
+}
+
+// isGlobalIPv6 reports whether the address is a global unicast IPv6 address,
+// the link-local and the unique local addresses are ignored.
+func isGlobalIPv6(addr netip.Addr) bool {
+	return addr.Is6() && !addr.Is4In6() && addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
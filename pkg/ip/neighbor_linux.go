@@ -0,0 +1,84 @@
+//go:build linux
+
+package ip
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// neighbor table constants from linux/neighbour.h
+const (
+	ndMsgLength = 12
+	ndaDst      = 1
+	ndaLLAddr   = 2
+	// entries in these states are not confirmed to exist
+	nudInvalid = 0x01 | 0x20 | 0x40 // INCOMPLETE, FAILED and NOARP
+)
+
+// listNeighbors dumps the IPv6 neighbor table with netlink RTM_GETNEIGH.
+func listNeighbors() ([]neighbor, error) {
+	data, err := syscall.NetlinkRIB(syscall.RTM_GETNEIGH, syscall.AF_INET6)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []neighbor
+	for _, m := range msgs {
+		if m.Header.Type == syscall.NLMSG_DONE {
+			break
+		}
+
+		if m.Header.Type != syscall.RTM_NEWNEIGH || len(m.Data) < ndMsgLength {
+			continue
+		}
+
+		state := binary.NativeEndian.Uint16(m.Data[8:10])
+		if state&nudInvalid != 0 {
+			continue
+		}
+
+		if n, ok := parseNeighborAttrs(m.Data[ndMsgLength:]); ok {
+			result = append(result, n)
+		}
+	}
+
+	return result, nil
+}
+
+// parseNeighborAttrs reads the destination and the link layer address of the entry.
+func parseNeighborAttrs(attrs []byte) (neighbor, bool) {
+	var n neighbor
+	for len(attrs) >= syscall.SizeofRtAttr {
+		length := int(binary.NativeEndian.Uint16(attrs[0:2]))
+		if length < syscall.SizeofRtAttr || length > len(attrs) {
+			break
+		}
+
+		value := attrs[syscall.SizeofRtAttr:length]
+		switch binary.NativeEndian.Uint16(attrs[2:4]) {
+		case ndaDst:
+			if addr, ok := netip.AddrFromSlice(value); ok {
+				n.addr = addr
+			}
+		case ndaLLAddr:
+			n.mac = net.HardwareAddr(append([]byte(nil), value...))
+		}
+
+		// attributes are aligned to 4 bytes
+		aligned := (length + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if aligned > len(attrs) {
+			break
+		}
+		attrs = attrs[aligned:]
+	}
+
+	return n, n.addr.IsValid() && len(n.mac) > 0
+}
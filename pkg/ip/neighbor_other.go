@@ -0,0 +1,10 @@
+//go:build !linux
+
+package ip
+
+import "errors"
+
+// listNeighbors is only supported on Linux.
+func listNeighbors() ([]neighbor, error) {
+	return nil, errors.New("the neighbor table is only supported on Linux")
+}
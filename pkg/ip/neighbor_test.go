@@ -0,0 +1,52 @@
+package ip
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+func TestSelectNeighbor(t *testing.T) {
+	hw, _ := net.ParseMAC("52:54:00:12:34:56")
+	other, _ := net.ParseMAC("52:54:00:ab:cd:ef")
+	prefix := netip.MustParsePrefix("2001:db8:1:200::/56")
+	helper := &IPHelper{configuration: &settings.Settings{Prefix: settings.Prefix{Enabled: true, NeighborGrace: 60}}}
+	now := time.Now()
+
+	stable := neighbor{netip.MustParseAddr("2001:db8:1:201:1111:2222:3333:4444"), hw}
+	entries := []neighbor{
+		{netip.MustParseAddr("fe80::5054:ff:fe12:3456"), hw},
+		{netip.MustParseAddr("fd00::1"), hw},
+		{netip.MustParseAddr("2001:db8:1:201::99"), other},
+		stable,
+	}
+
+	addr, err := helper.selectNeighbor(entries, hw, prefix, now)
+	if err != nil || addr != stable.addr {
+		t.Fatalf("expected %s, got %s (%v)", stable.addr, addr, err)
+	}
+
+	// the temporary address which appeared later is not preferred
+	temporary := neighbor{netip.MustParseAddr("2001:db8:1:201:aaaa:bbbb:cccc:dddd"), hw}
+	if addr, _ = helper.selectNeighbor([]neighbor{temporary, stable}, hw, prefix, now.Add(time.Second)); addr != stable.addr {
+		t.Errorf("expected %s, got %s", stable.addr, addr)
+	}
+
+	// the EUI-64 address is preferred over all
+	eui := neighbor{netip.MustParseAddr("2001:db8:1:201:5054:ff:fe12:3456"), hw}
+	if addr, _ = helper.selectNeighbor([]neighbor{eui, stable}, hw, prefix, now.Add(2*time.Second)); addr != eui.addr {
+		t.Errorf("expected %s, got %s", eui.addr, addr)
+	}
+
+	// the device which dropped out is kept during the grace period
+	if addr, err = helper.selectNeighbor(nil, hw, prefix, now.Add(30*time.Second)); err != nil || addr != eui.addr {
+		t.Errorf("expected %s during the grace period, got %s (%v)", eui.addr, addr, err)
+	}
+
+	if _, err = helper.selectNeighbor(nil, hw, prefix, now.Add(2*time.Minute)); err == nil {
+		t.Error("device should be forgotten after the grace period")
+	}
+}
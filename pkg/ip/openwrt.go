@@ -0,0 +1,179 @@
+package ip
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// ubusNullSession is the session ID used to log in to rpcd.
+const ubusNullSession = "00000000000000000000000000000000"
+
+type ubusRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type ubusResponse struct {
+	Result []json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type ubusAddress struct {
+	Address string `json:"address"`
+	Mask    int    `json:"mask"`
+}
+
+type ubusInterfaceStatus struct {
+	Up   bool          `json:"up"`
+	IPv4 []ubusAddress `json:"ipv4-address"`
+	IPv6 []ubusAddress `json:"ipv6-address"`
+}
+
+// getIPFromOpenWrt gets the IP of the WAN interface from the OpenWrt router with ubus.
+func (helper *IPHelper) getIPFromOpenWrt(ctx context.Context) (string, error) {
+	conf := helper.configuration.OpenWrt
+	u, err := url.Parse(conf.Addr)
+	if err != nil {
+		return "", fmt.Errorf("fail to parse openwrt address: %w", err)
+	}
+
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/ubus"
+	}
+
+	ipv6 := strings.ToUpper(helper.configuration.IPType) == utils.IPV6
+	iface := conf.Interface
+	if iface == "" {
+		iface = "wan"
+		if ipv6 {
+			iface = "wan6"
+		}
+	}
+
+	client := helper.newRouterClient(conf.SkipVerify)
+	var login struct {
+		Session string `json:"ubus_rpc_session"`
+	}
+
+	args := map[string]string{"username": conf.Username, "password": conf.Password}
+	if err = callUbus(ctx, client, u.String(), ubusNullSession, "session", "login", args, &login); err != nil {
+		return "", fmt.Errorf("openwrt login failed: %w", err)
+	}
+
+	var status ubusInterfaceStatus
+	if err = callUbus(ctx, client, u.String(), login.Session, "network.interface."+iface, "status", map[string]string{}, &status); err != nil {
+		return "", fmt.Errorf("get status of openwrt interface %s failed: %w", iface, err)
+	}
+
+	if !status.Up {
+		return "", errors.New("openwrt interface " + iface + " is down")
+	}
+
+	addrs := status.IPv4
+	if ipv6 {
+		addrs = status.IPv6
+	}
+
+	for _, a := range addrs {
+		addr, err := parseIP(a.Address, ipv6)
+		if err != nil || !addr.IsGlobalUnicast() {
+			continue
+		}
+
+		ip := addr.String()
+		log.Printf("Get ip success from openwrt: %s, IP: %s", u.Host, ip)
+		return ip, nil
+	}
+
+	return "", errors.New("openwrt interface " + iface + " has no address of the IP type")
+}
+
+// callUbus calls the method of the ubus object with the JSON-RPC API of rpcd.
+func callUbus(ctx context.Context, client *http.Client, endpoint, session, object, method string, args, result interface{}) error {
+	content, err := json.Marshal(ubusRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "call",
+		Params:  []interface{}{session, object, method, args},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request %s got httpCode:%d, body: %s", endpoint, resp.StatusCode, string(body))
+	}
+
+	var response ubusResponse
+	if err = json.Unmarshal(body, &response); err != nil {
+		return err
+	}
+
+	if response.Error != nil {
+		return fmt.Errorf("ubus error %d: %s", response.Error.Code, response.Error.Message)
+	}
+
+	// the result is the ubus status code followed by the data
+	if len(response.Result) == 0 {
+		return errors.New("empty ubus result")
+	}
+
+	var code int
+	if err = json.Unmarshal(response.Result[0], &code); err != nil {
+		return err
+	}
+
+	if code != 0 {
+		return fmt.Errorf("ubus call %s %s returned status %d", object, method, code)
+	}
+
+	if len(response.Result) < 2 {
+		return errors.New("ubus call " + object + " " + method + " returned no data")
+	}
+
+	return json.Unmarshal(response.Result[1], result)
+}
+
+// newRouterClient returns the client for the API of the router on LAN, the proxy is not used.
+func (helper *IPHelper) newRouterClient(skipVerify bool) *http.Client {
+	return &http.Client{
+		Timeout: time.Second * utils.DefaultTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: skipVerify || helper.configuration.SkipSSLVerify},
+		},
+	}
+}
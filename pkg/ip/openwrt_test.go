@@ -0,0 +1,71 @@
+package ip
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// startUbus starts a fake rpcd which allows the status of the WAN interfaces after the login.
+func startUbus(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ubusRequest
+		if r.URL.Path != "/ubus" || json.NewDecoder(r.Body).Decode(&req) != nil || len(req.Params) != 4 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case req.Params[1] == "session" && req.Params[2] == "login":
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":[0,{"ubus_rpc_session":"c0ffee"}]}`)
+		case req.Params[0] != "c0ffee":
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32002,"message":"Access denied"}}`)
+		case req.Params[1] == "network.interface.wan":
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":[0,{"up":true,"ipv4-address":[{"address":"203.0.113.20","mask":24}]}]}`)
+		case req.Params[1] == "network.interface.wan6":
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":[0,{"up":true,"ipv6-address":[{"address":"2001:db8::20","mask":64}]}]}`)
+		default:
+			// UBUS_STATUS_NOT_FOUND
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":[4]}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestGetIPFromOpenWrt(t *testing.T) {
+	addr := startUbus(t)
+	for ipType, expected := range map[string]string{utils.IPV4: "203.0.113.20", utils.IPV6: "2001:db8::20"} {
+		helper := &IPHelper{configuration: &settings.Settings{
+			IPType:  ipType,
+			OpenWrt: settings.OpenWrt{Enabled: true, Addr: addr, Username: "root", Password: "secret"},
+		}}
+
+		ip, err := helper.getIPFromOpenWrt(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ip != expected {
+			t.Errorf("expected %s, got %s", expected, ip)
+		}
+	}
+}
+
+func TestGetIPFromOpenWrtUnknownInterface(t *testing.T) {
+	helper := &IPHelper{configuration: &settings.Settings{
+		IPType:  utils.IPV4,
+		OpenWrt: settings.OpenWrt{Enabled: true, Addr: startUbus(t), Interface: "lte"},
+	}}
+
+	if _, err := helper.getIPFromOpenWrt(context.Background()); err == nil {
+		t.Error("expected error for the unknown interface")
+	}
+}
@@ -0,0 +1,320 @@
+package ip
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+	"github.com/pchchv/goddns/pkg/safe"
+)
+
+// IPResult is the detected IP with the source it was got from.
+type IPResult struct {
+	Addr       netip.Addr    `json:"addr"`
+	Source     string        `json:"source"`
+	Latency    time.Duration `json:"-"`
+	DetectedAt time.Time     `json:"detected_at"`
+}
+
+// MarshalJSON encodes the result with the latency in milliseconds.
+func (r IPResult) MarshalJSON() ([]byte, error) {
+	type result IPResult
+	return json.Marshal(struct {
+		result
+		LatencyMS int64 `json:"latency_ms"`
+	}{result(r), r.Latency.Milliseconds()})
+}
+
+// sourceFunc returns the function which gets the IP from the source type.
+func (helper *IPHelper) sourceFunc(sourceType string) (func(context.Context) (string, error), error) {
+	switch sourceType {
+	case utils.IPSourceMikrotik:
+		return helper.getIPFromMikrotik, nil
+	case utils.IPSourceOpenWrt:
+		return helper.getIPFromOpenWrt, nil
+	case utils.IPSourceFritzBox:
+		return helper.getIPFromFritzBox, nil
+	case utils.IPSourceUPnP:
+		return helper.getIPFromUPnP, nil
+	case utils.IPSourceNATPMP:
+		return helper.getIPFromNATPMP, nil
+	case utils.IPSourceSTUN:
+		return helper.getIPFromSTUN, nil
+	case utils.IPSourceOnline:
+		return helper.getIPOnline, nil
+	case utils.IPSourceInterface:
+		return helper.getIPFromInterface, nil
+	default:
+		return nil, errors.New("unknown IP source type: " + sourceType)
+	}
+}
+
+// isLocalSource reports whether the source reads the IP from the router or the host.
+func isLocalSource(sourceType string) bool {
+	return sourceType != utils.IPSourceSTUN && sourceType != utils.IPSourceOnline
+}
+
+// pipelineSources returns the configured sources, or the enabled ones in the default order.
+// The WAN helpers use the sources of the WAN, the router sources only if they are listed there.
+func (helper *IPHelper) pipelineSources() []settings.IPSource {
+	if helper.wan != "" {
+		return helper.wanPipelineSources()
+	}
+
+	conf := helper.configuration
+	if len(conf.IPDetect.Sources) > 0 {
+		return conf.IPDetect.Sources
+	}
+
+	var sources []settings.IPSource
+	for _, s := range []struct {
+		sourceType string
+		enabled    bool
+	}{
+		{utils.IPSourceMikrotik, conf.Mikrotik.Enabled},
+		{utils.IPSourceOpenWrt, conf.OpenWrt.Enabled},
+		{utils.IPSourceFritzBox, conf.FritzBox.Enabled},
+		{utils.IPSourceUPnP, conf.UPnP.Enabled},
+		{utils.IPSourceNATPMP, conf.NATPMP.Enabled},
+		{utils.IPSourceSTUN, conf.STUN.Enabled},
+		{utils.IPSourceOnline, len(helper.reqURLs) > 0},
+		{utils.IPSourceInterface, conf.IPInterface != ""},
+	} {
+		if s.enabled {
+			sources = append(sources, settings.IPSource{Type: s.sourceType})
+		}
+	}
+
+	return sources
+}
+
+// wanPipelineSources returns the sources of the WAN, or the ones which go through its binding.
+func (helper *IPHelper) wanPipelineSources() []settings.IPSource {
+	if len(helper.wanSources) > 0 {
+		return helper.wanSources
+	}
+
+	var sources []settings.IPSource
+	if helper.configuration.STUN.Enabled {
+		sources = append(sources, settings.IPSource{Type: utils.IPSourceSTUN})
+	}
+
+	if len(helper.reqURLs) > 0 {
+		sources = append(sources, settings.IPSource{Type: utils.IPSourceOnline})
+	}
+
+	if helper.ipInterface() != "" {
+		sources = append(sources, settings.IPSource{Type: utils.IPSourceInterface})
+	}
+
+	return sources
+}
+
+// detectIP returns the IP from the sources with the fallback mode.
+func (helper *IPHelper) detectIP() (IPResult, error) {
+	sources := helper.pipelineSources()
+	if len(sources) == 0 {
+		return IPResult{}, errors.New("no IP source is configured")
+	}
+
+	switch helper.configuration.IPDetect.Fallback {
+	case utils.IPFallbackAllAgree:
+		return helper.detectAllAgree(sources)
+	case utils.IPFallbackPreferLocal:
+		sources = append([]settings.IPSource(nil), sources...)
+		sort.SliceStable(sources, func(i, j int) bool {
+			return isLocalSource(sources[i].Type) && !isLocalSource(sources[j].Type)
+		})
+	}
+
+	return helper.detectFirstSuccess(sources)
+}
+
+// detectFirstSuccess returns the IP from the first source in the order which succeeds.
+// The low trust sources are used only if no other source succeeds.
+func (helper *IPHelper) detectFirstSuccess(sources []settings.IPSource) (IPResult, error) {
+	var fallback *IPResult
+	for _, source := range sources {
+		if fallback != nil && source.Trust == utils.IPTrustLow {
+			continue
+		}
+
+		result, err := helper.runSource(source)
+		if err != nil {
+			log.Printf("get ip from %s failed: %v", source.Type, err)
+			continue
+		}
+
+		if source.Trust != utils.IPTrustLow {
+			return result, nil
+		}
+
+		fallback = &result
+	}
+
+	if fallback == nil {
+		return IPResult{}, errors.New("all the IP sources failed")
+	}
+
+	log.Printf("Only the low trust IP source %s succeeded", fallback.Source)
+	return *fallback, nil
+}
+
+// detectAllAgree queries all the sources and returns the IP if they agree.
+// If they don't, the IP of the high trust sources is used if these agree.
+// The low trust sources are used only if no other source succeeds.
+func (helper *IPHelper) detectAllAgree(sources []settings.IPSource) (IPResult, error) {
+	results := make([]*IPResult, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := helper.runSource(source)
+			if err != nil {
+				log.Printf("get ip from %s failed: %v", source.Type, err)
+				return
+			}
+
+			results[i] = &result
+		}()
+	}
+	wg.Wait()
+
+	collect := func(levels ...string) []IPResult {
+		var collected []IPResult
+		for i, r := range results {
+			trust := sources[i].Trust
+			if trust == "" {
+				trust = utils.IPTrustNormal
+			}
+
+			for _, level := range levels {
+				if r != nil && trust == level {
+					collected = append(collected, *r)
+				}
+			}
+		}
+		return collected
+	}
+
+	for _, levels := range [][]string{{utils.IPTrustNormal, utils.IPTrustHigh}, {utils.IPTrustLow}} {
+		candidates := collect(levels...)
+		if len(candidates) == 0 {
+			continue
+		}
+
+		if result, ok := agreedResult(candidates); ok {
+			return result, nil
+		}
+
+		if result, ok := agreedResult(collect(utils.IPTrustHigh)); ok {
+			log.Printf("IP sources disagree %s, using %s of the high trust sources", describeResults(candidates), result.Addr)
+			return result, nil
+		}
+
+		return IPResult{}, errors.New("IP sources disagree: " + describeResults(candidates))
+	}
+
+	return IPResult{}, errors.New("all the IP sources failed")
+}
+
+// agreedResult returns the first result if all the results have the same IP.
+func agreedResult(results []IPResult) (IPResult, bool) {
+	if len(results) == 0 {
+		return IPResult{}, false
+	}
+
+	for _, r := range results[1:] {
+		if r.Addr != results[0].Addr {
+			return IPResult{}, false
+		}
+	}
+
+	return results[0], true
+}
+
+func describeResults(results []IPResult) string {
+	described := make([]string, 0, len(results))
+	for _, r := range results {
+		described = append(described, r.Source+"="+r.Addr.String())
+	}
+	return "[" + strings.Join(described, ", ") + "]"
+}
+
+// runSource gets the IP from the source, it is retried on failure.
+func (helper *IPHelper) runSource(source settings.IPSource) (IPResult, error) {
+	get, err := helper.sourceFunc(source.Type)
+	if err != nil {
+		return IPResult{}, err
+	}
+
+	timeout := time.Duration(source.Timeout) * time.Second
+	for attempt := 0; attempt <= source.Retries; attempt++ {
+		if attempt > 0 {
+			log.Printf("Retry to get ip from %s (%d/%d): %v", source.Type, attempt, source.Retries, err)
+		}
+
+		start := time.Now()
+		var ip string
+		if ip, err = callWithTimeout(get, timeout); err == nil {
+			err = helper.checkIP(ip)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return IPResult{}, err
+		}
+
+		return IPResult{
+			Addr:       addr,
+			Source:     source.Type,
+			Latency:    time.Since(start),
+			DetectedAt: time.Now(),
+		}, nil
+	}
+
+	return IPResult{}, err
+}
+
+// callWithTimeout stops waiting for the source after the timeout, the zero timeout waits until it returns.
+// The context of the source is canceled on the timeout, so that it stops its requests.
+func callWithTimeout(get func(context.Context) (string, error), timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return get(context.Background())
+	}
+
+	type result struct {
+		ip  string
+		err error
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan result, 1)
+	safe.SafeGo(func() {
+		ip, err := get(ctx)
+		done <- result{ip, err}
+	})
+
+	select {
+	case r := <-done:
+		return r.ip, r.err
+	case <-ctx.Done():
+		return "", fmt.Errorf("timed out after %s", timeout)
+	}
+}
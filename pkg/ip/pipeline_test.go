@@ -0,0 +1,118 @@
+package ip
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// newPipelineHelper returns the helper with the online source answering 203.0.113.1
+// and the OpenWrt source answering 203.0.113.20.
+func newPipelineHelper(t *testing.T, detect settings.IPDetect) *IPHelper {
+	helper := newQuorumHelper([]string{startEchoServer(t, "203.0.113.1")}, settings.IPQuorum{})
+	helper.configuration.OpenWrt = settings.OpenWrt{Enabled: true, Addr: startUbus(t)}
+	helper.configuration.IPDetect = detect
+	return helper
+}
+
+func TestDetectIP(t *testing.T) {
+	online := settings.IPSource{Type: utils.IPSourceOnline}
+	openwrt := settings.IPSource{Type: utils.IPSourceOpenWrt, Retries: 1, Timeout: 5}
+	for _, tc := range []struct {
+		name     string
+		detect   settings.IPDetect
+		expected string
+		source   string
+	}{
+		{"default order", settings.IPDetect{}, "203.0.113.20", utils.IPSourceOpenWrt},
+		{"first success", settings.IPDetect{Sources: []settings.IPSource{online, openwrt}}, "203.0.113.1", utils.IPSourceOnline},
+		{"low trust", settings.IPDetect{Sources: []settings.IPSource{{Type: utils.IPSourceOnline, Trust: utils.IPTrustLow}, openwrt}}, "203.0.113.20", utils.IPSourceOpenWrt},
+		{"prefer local", settings.IPDetect{Sources: []settings.IPSource{online, openwrt}, Fallback: utils.IPFallbackPreferLocal}, "203.0.113.20", utils.IPSourceOpenWrt},
+		{"high trust wins", settings.IPDetect{Sources: []settings.IPSource{online, {Type: utils.IPSourceOpenWrt, Trust: utils.IPTrustHigh}}, Fallback: utils.IPFallbackAllAgree}, "203.0.113.20", utils.IPSourceOpenWrt},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := newPipelineHelper(t, tc.detect).detectIP()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Addr.String() != tc.expected || result.Source != tc.source {
+				t.Errorf("expected %s from %s, got %s from %s", tc.expected, tc.source, result.Addr, result.Source)
+			}
+		})
+	}
+}
+
+func TestDetectIPDisagree(t *testing.T) {
+	helper := newPipelineHelper(t, settings.IPDetect{
+		Sources:  []settings.IPSource{{Type: utils.IPSourceOnline}, {Type: utils.IPSourceOpenWrt}},
+		Fallback: utils.IPFallbackAllAgree,
+	})
+
+	if result, err := helper.detectIP(); err == nil {
+		t.Errorf("expected the sources to disagree, got %s from %s", result.Addr, result.Source)
+	}
+}
+
+func TestDetectIPWAN(t *testing.T) {
+	helper := newPipelineHelper(t, settings.IPDetect{})
+	helper.wan = "backup"
+	helper.bind = settings.Bind{Address: "127.0.0.1"}
+
+	result, err := helper.detectIP()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the router of the default uplink is not asked for the IP of the WAN
+	if result.Source != utils.IPSourceOnline {
+		t.Errorf("expected the IP from %s, got %s from %s", utils.IPSourceOnline, result.Addr, result.Source)
+	}
+
+	helper.wanSources = []settings.IPSource{{Type: utils.IPSourceOpenWrt}}
+	if result, err = helper.detectIP(); err != nil {
+		t.Fatal(err)
+	} else if result.Source != utils.IPSourceOpenWrt {
+		t.Errorf("expected the IP from %s, got %s from %s", utils.IPSourceOpenWrt, result.Addr, result.Source)
+	}
+}
+
+func TestCallWithTimeoutCancel(t *testing.T) {
+	canceled := make(chan struct{})
+	_, err := callWithTimeout(func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		close(canceled)
+		return "", ctx.Err()
+	}, 10*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected the timeout error")
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("the context of the source is not canceled on the timeout")
+	}
+}
+
+func TestIPResultJSON(t *testing.T) {
+	result := IPResult{Addr: netip.MustParseAddr("203.0.113.1"), Source: utils.IPSourceOnline, Latency: 1500 * time.Millisecond}
+	content, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]interface{}
+	if err = json.Unmarshal(content, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded["latency_ms"] != 1500.0 || decoded["addr"] != "203.0.113.1" || decoded["source"] != utils.IPSourceOnline {
+		t.Errorf("unexpected JSON of the result: %s", content)
+	}
+}
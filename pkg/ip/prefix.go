@@ -0,0 +1,100 @@
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+// defaultPrefixLength is the length of the LAN subnet prefix.
+const defaultPrefixLength = 64
+
+// PrefixOf returns the delegated prefix of the IPv6 address.
+func (helper *IPHelper) PrefixOf(ip string) (netip.Prefix, error) {
+	if ip == "" {
+		return netip.Prefix{}, errors.New("IP is unknown")
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	if !addr.Is6() || addr.Is4In6() {
+		return netip.Prefix{}, errors.New("prefix delegation requires an IPv6 address, got " + ip)
+	}
+
+	length := helper.configuration.Prefix.PrefixLength
+	if length == 0 {
+		length = defaultPrefixLength
+	}
+
+	return addr.WithZone("").Prefix(length)
+}
+
+// HostAddress builds the address of the LAN host in the prefix.
+// The host bits are taken from the suffix and from EUI-64 of the MAC.
+func HostAddress(prefix netip.Prefix, host settings.LANHost) (netip.Addr, error) {
+	if !prefix.Addr().Is6() {
+		return netip.Addr{}, errors.New("prefix should be IPv6: " + prefix.String())
+	}
+
+	var id [16]byte
+	if host.Suffix != "" {
+		suffix, err := netip.ParseAddr(host.Suffix)
+		if err != nil || !suffix.Is6() {
+			return netip.Addr{}, fmt.Errorf("invalid suffix of %s: %s", host.SubDomain, host.Suffix)
+		}
+		id = suffix.As16()
+	}
+
+	if host.MAC != "" {
+		eui, err := eui64(host.MAC)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("invalid MAC of %s: %w", host.SubDomain, err)
+		}
+
+		for i := range eui {
+			id[8+i] |= eui[i]
+		}
+	}
+
+	addr := prefix.Masked().Addr().As16()
+	bits := prefix.Bits()
+	for i := range addr {
+		// only the bits after the prefix are taken from the identifier
+		mask := byte(0xff)
+		if start := i * 8; start < bits {
+			if bits-start >= 8 {
+				continue
+			}
+			mask = 0xff >> (bits - start)
+		}
+		addr[i] |= id[i] & mask
+	}
+
+	return netip.AddrFrom16(addr), nil
+}
+
+// eui64 builds the modified EUI-64 interface identifier from the MAC (RFC 4291).
+func eui64(mac string) ([8]byte, error) {
+	var id [8]byte
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return id, err
+	}
+
+	if len(hw) != 6 {
+		return id, errors.New("EUI-64 requires a 48-bit MAC: " + mac)
+	}
+
+	copy(id[0:3], hw[0:3])
+	id[3], id[4] = 0xff, 0xfe
+	copy(id[5:8], hw[3:6])
+	// flip the universal/local bit
+	id[0] ^= 0x02
+	return id, nil
+}
@@ -0,0 +1,51 @@
+package ip
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+func TestHostAddress(t *testing.T) {
+	tests := []struct {
+		prefix string
+		host   settings.LANHost
+		addr   string
+	}{
+		{"2001:db8:1:2::/64", settings.LANHost{Suffix: "::10"}, "2001:db8:1:2::10"},
+		{"2001:db8:1:2::/64", settings.LANHost{MAC: "52:54:00:12:34:56"}, "2001:db8:1:2:5054:ff:fe12:3456"},
+		// the subnet ID is set by the suffix in the delegated /56
+		{"2001:db8:1:200::/56", settings.LANHost{Suffix: "0:0:0:3::", MAC: "52:54:00:12:34:56"}, "2001:db8:1:203:5054:ff:fe12:3456"},
+		// the prefix bits are never overwritten
+		{"2001:db8:1:2::/64", settings.LANHost{Suffix: "ffff:ffff:ffff:ffff::1"}, "2001:db8:1:2::1"},
+	}
+
+	for _, tt := range tests {
+		addr, err := HostAddress(netip.MustParsePrefix(tt.prefix), tt.host)
+		if err != nil {
+			t.Errorf("%s %+v: %s", tt.prefix, tt.host, err)
+			continue
+		}
+
+		if addr.String() != tt.addr {
+			t.Errorf("%s %+v: expected %s, got %s", tt.prefix, tt.host, tt.addr, addr)
+		}
+	}
+}
+
+func TestPrefixOf(t *testing.T) {
+	helper := &IPHelper{configuration: &settings.Settings{Prefix: settings.Prefix{Enabled: true, PrefixLength: 56}}}
+	prefix, err := helper.PrefixOf("2001:db8:1:2ff::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if prefix.String() != "2001:db8:1:200::/56" {
+		t.Errorf("expected 2001:db8:1:200::/56, got %s", prefix)
+	}
+
+	if _, err = helper.PrefixOf("203.0.113.1"); err == nil {
+		t.Error("IPv4 address should be rejected")
+	}
+}
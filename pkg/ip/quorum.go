@@ -0,0 +1,84 @@
+package ip
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+// getIPByQuorum queries the IP sources in parallel and accepts the IP
+// only if enough of them agree on it. The sources which disagree with the quorum
+// are reported and deprioritized like the failing ones.
+func (helper *IPHelper) getIPByQuorum(ctx context.Context, client *http.Client, sources []settings.IPURL) string {
+	quorum := helper.configuration.IPQuorum
+	n := quorum.Sources
+	if n <= 0 || n > len(sources) {
+		n = len(sources)
+	}
+
+	required := quorum.Required
+	if required <= 0 {
+		// the majority of the queried sources by default
+		required = n/2 + 1
+	}
+
+	answers := make([]string, n)
+	var wg sync.WaitGroup
+	for i, source := range sources[:n] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ip, err := helper.querySource(ctx, client, source)
+			if err != nil {
+				log.Println("Cannot get IP:", err)
+				return
+			}
+
+			answers[i] = ip
+		}()
+	}
+	wg.Wait()
+
+	votes := map[string][]string{}
+	for i, ip := range answers {
+		if ip != "" {
+			votes[ip] = append(votes[ip], sources[i].URL)
+		}
+	}
+
+	var onlineIP string
+	for ip, voters := range votes {
+		if len(voters) > len(votes[onlineIP]) || (len(voters) == len(votes[onlineIP]) && ip < onlineIP) {
+			onlineIP = ip
+		}
+	}
+
+	if len(votes[onlineIP]) < required {
+		log.Printf("IP quorum is not reached: %d of %d sources are required to agree, got %v", required, n, votes)
+		return ""
+	}
+
+	disagreeing := make([]string, 0, len(votes))
+	for ip, voters := range votes {
+		if ip == onlineIP {
+			continue
+		}
+
+		for _, reqURL := range voters {
+			helper.recordFailure(reqURL)
+			disagreeing = append(disagreeing, reqURL+" ("+ip+")")
+		}
+	}
+
+	if len(disagreeing) > 0 {
+		sort.Strings(disagreeing)
+		log.Printf("IP sources disagree with the quorum IP %s: %v", onlineIP, disagreeing)
+	}
+
+	log.Printf("IP quorum reached: %d of %d sources agree on %s", len(votes[onlineIP]), n, onlineIP)
+	return onlineIP
+}
@@ -0,0 +1,54 @@
+package ip
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// startEchoServer starts a local IP echo service answering with ip.
+func startEchoServer(t *testing.T, ip string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, ip)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func newQuorumHelper(urls []string, quorum settings.IPQuorum) *IPHelper {
+	helper := &IPHelper{idx: -1}
+	conf := &settings.Settings{IPType: utils.IPV4, IPQuorum: quorum}
+	for _, url := range urls {
+		conf.IPUrls = append(conf.IPUrls, settings.IPURL{URL: url})
+	}
+	helper.UpdateConfiguration(conf)
+	return helper
+}
+
+func TestGetIPByQuorum(t *testing.T) {
+	hijacked := startEchoServer(t, "198.51.100.1")
+	urls := []string{hijacked, startEchoServer(t, "203.0.113.1"), startEchoServer(t, "203.0.113.1")}
+	helper := newQuorumHelper(urls, settings.IPQuorum{Enabled: true})
+	if ip, _ := helper.getIPOnline(context.Background()); ip != "203.0.113.1" {
+		t.Fatalf("expected 203.0.113.1, got %s", ip)
+	}
+
+	// the disagreeing source should be queried last
+	if sources := helper.orderedSources(); sources[len(sources)-1].URL != hijacked {
+		t.Errorf("expected %s to be deprioritized, got %v", hijacked, sources)
+	}
+}
+
+func TestGetIPByQuorumNotReached(t *testing.T) {
+	urls := []string{startEchoServer(t, "198.51.100.1"), startEchoServer(t, "203.0.113.1"), "http://127.0.0.1:1"}
+	helper := newQuorumHelper(urls, settings.IPQuorum{Enabled: true, Required: 2})
+	if ip, err := helper.getIPOnline(context.Background()); err == nil {
+		t.Errorf("quorum should not be reached, got %s", ip)
+	}
+}
@@ -0,0 +1,182 @@
+package ip
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// routerOSConn is the connection to the RouterOS binary API.
+type routerOSConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func newRouterOSConn(conn net.Conn) *routerOSConn {
+	return &routerOSConn{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *routerOSConn) Close() error {
+	return c.conn.Close()
+}
+
+// login logs in with the plain password (RouterOS 6.43 and later)
+// or with the MD5 challenge of the older versions.
+func (c *routerOSConn) login(username, password string) error {
+	_, done, err := c.run("/login", "=name="+username, "=password="+password)
+	if err != nil {
+		return err
+	}
+
+	ret, ok := done["ret"]
+	if !ok {
+		return nil
+	}
+
+	challenge, err := hex.DecodeString(ret)
+	if err != nil {
+		return fmt.Errorf("invalid login challenge: %w", err)
+	}
+
+	h := md5.New()
+	h.Write([]byte{0})
+	h.Write([]byte(password))
+	h.Write(challenge)
+	_, _, err = c.run("/login", "=name="+username, "=response=00"+hex.EncodeToString(h.Sum(nil)))
+	return err
+}
+
+// run sends the command and returns the attributes of the replies and of the final !done.
+func (c *routerOSConn) run(words ...string) ([]map[string]string, map[string]string, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(10 * time.Second)); err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.writeSentence(words); err != nil {
+		return nil, nil, err
+	}
+
+	var replies []map[string]string
+	var trap error
+	for {
+		sentence, err := c.readSentence()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(sentence) == 0 {
+			continue
+		}
+
+		attrs := map[string]string{}
+		for _, word := range sentence[1:] {
+			if kv, ok := strings.CutPrefix(word, "="); ok {
+				key, value, _ := strings.Cut(kv, "=")
+				attrs[key] = value
+			}
+		}
+
+		switch sentence[0] {
+		case "!re":
+			replies = append(replies, attrs)
+		case "!trap":
+			trap = errors.New("RouterOS API error: " + attrs["message"])
+		case "!fatal":
+			return nil, nil, errors.New("RouterOS API fatal error: " + strings.Join(sentence[1:], " "))
+		case "!done":
+			if trap != nil {
+				return nil, nil, trap
+			}
+			return replies, attrs, nil
+		}
+	}
+}
+
+func (c *routerOSConn) writeSentence(words []string) error {
+	var buf []byte
+	for _, word := range words {
+		buf = appendRouterOSLength(buf, len(word))
+		buf = append(buf, word...)
+	}
+	// the empty word ends the sentence
+	buf = append(buf, 0)
+
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+func (c *routerOSConn) readSentence() ([]string, error) {
+	var sentence []string
+	for {
+		length, err := readRouterOSLength(c.r)
+		if err != nil {
+			return nil, err
+		}
+
+		if length == 0 {
+			return sentence, nil
+		}
+
+		word := make([]byte, length)
+		if _, err = io.ReadFull(c.r, word); err != nil {
+			return nil, err
+		}
+		sentence = append(sentence, string(word))
+	}
+}
+
+// appendRouterOSLength encodes the word length with the variable length encoding of the API.
+func appendRouterOSLength(buf []byte, l int) []byte {
+	switch {
+	case l < 0x80:
+		return append(buf, byte(l))
+	case l < 0x4000:
+		return append(buf, byte(l>>8)|0x80, byte(l))
+	case l < 0x200000:
+		return append(buf, byte(l>>16)|0xc0, byte(l>>8), byte(l))
+	case l < 0x10000000:
+		return append(buf, byte(l>>24)|0xe0, byte(l>>16), byte(l>>8), byte(l))
+	default:
+		return append(buf, 0xf0, byte(l>>24), byte(l>>16), byte(l>>8), byte(l))
+	}
+}
+
+func readRouterOSLength(r *bufio.Reader) (int, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	var extra int
+	length := int(first)
+	switch {
+	case first&0x80 == 0:
+		return length, nil
+	case first&0xc0 == 0x80:
+		extra, length = 1, length&0x3f
+	case first&0xe0 == 0xc0:
+		extra, length = 2, length&0x1f
+	case first&0xf0 == 0xe0:
+		extra, length = 3, length&0x0f
+	case first == 0xf0:
+		extra, length = 4, 0
+	default:
+		return 0, fmt.Errorf("invalid RouterOS API word length 0x%x", first)
+	}
+
+	for i := 0; i < extra; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		length = length<<8 | int(b)
+	}
+
+	return length, nil
+}
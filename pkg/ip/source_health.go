@@ -0,0 +1,107 @@
+package ip
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+)
+
+const (
+	// slowSourceLatency marks the sources which are queried after the faster ones.
+	slowSourceLatency = 3 * time.Second
+	// the failing sources are skipped with the exponential backoff
+	sourceBackoffBase = 30 * time.Second
+	sourceBackoffMax  = 30 * time.Minute
+)
+
+// sourceHealth keeps the statistics of an IP source.
+type sourceHealth struct {
+	failures int
+	latency  time.Duration // moving average of the successful requests
+	retryAt  time.Time
+}
+
+// recordSuccess updates the source statistics after the successful request.
+func (helper *IPHelper) recordSuccess(source string, latency time.Duration) {
+	helper.healthMutex.Lock()
+	defer helper.healthMutex.Unlock()
+
+	h := helper.getSourceHealth(source)
+	h.failures = 0
+	h.retryAt = time.Time{}
+	if h.latency == 0 {
+		h.latency = latency
+	} else {
+		h.latency = (h.latency*3 + latency) / 4
+	}
+}
+
+// recordFailure updates the source statistics after the failed request,
+// the source is skipped until the backoff expires.
+func (helper *IPHelper) recordFailure(source string) {
+	helper.healthMutex.Lock()
+	defer helper.healthMutex.Unlock()
+
+	h := helper.getSourceHealth(source)
+	h.failures++
+	backoff := sourceBackoffBase << min(h.failures-1, 10)
+	if backoff > sourceBackoffMax {
+		backoff = sourceBackoffMax
+	}
+	h.retryAt = time.Now().Add(backoff)
+}
+
+func (helper *IPHelper) getSourceHealth(source string) *sourceHealth {
+	if helper.health == nil {
+		helper.health = map[string]*sourceHealth{}
+	}
+
+	h, ok := helper.health[source]
+	if !ok {
+		h = &sourceHealth{}
+		helper.health[source] = h
+	}
+
+	return h
+}
+
+// orderedSources returns the IP sources in the order they should be queried.
+// The sources are rotated to spread the requests, the slow sources go after the fast ones
+// and the sources in backoff go last, so they are only used if nothing else answers.
+func (helper *IPHelper) orderedSources() []settings.IPURL {
+	newIdx := atomic.AddInt64(&helper.idx, 1)
+	helper.mutex.RLock()
+	sources := make([]settings.IPURL, 0, len(helper.reqURLs))
+	if len(helper.reqURLs) > 0 {
+		start := int(newIdx % int64(len(helper.reqURLs)))
+		sources = append(sources, helper.reqURLs[start:]...)
+		sources = append(sources, helper.reqURLs[:start]...)
+	}
+	helper.mutex.RUnlock()
+
+	helper.healthMutex.Lock()
+	defer helper.healthMutex.Unlock()
+
+	now := time.Now()
+	rank := func(source string) int {
+		h, ok := helper.health[source]
+		switch {
+		case !ok:
+			return 0
+		case now.Before(h.retryAt):
+			return 2
+		case h.latency > slowSourceLatency:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		return rank(sources[i].URL) < rank(sources[j].URL)
+	})
+
+	return sources
+}
@@ -0,0 +1,209 @@
+package ip
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// STUN message constants from RFC 5389.
+const (
+	stunBindingRequest       = 0x0001
+	stunBindingSuccess       = 0x0101
+	stunMagicCookie          = 0x2112A442
+	stunHeaderLength         = 20
+	stunAttrMappedAddress    = 0x0001
+	stunAttrXORMappedAddress = 0x0020
+	stunAttrXORMappedOld     = 0x8020 // used by pre RFC 5389 servers
+	stunFamilyIPv4           = 0x01
+	stunFamilyIPv6           = 0x02
+	stunTimeout              = 3 * time.Second
+)
+
+var (
+	defaultSTUNServers     = []string{"stun.l.google.com:19302", "stun.cloudflare.com:3478"}
+	defaultIPV6STUNServers = []string{"stun.l.google.com:19302", "stun.cloudflare.com:3478"}
+)
+
+// getIPFromSTUN gets public IP with a STUN binding request,
+// servers are tried in order until one of them answers.
+func (helper *IPHelper) getIPFromSTUN(ctx context.Context) (string, error) {
+	network := "udp4"
+	servers := helper.configuration.STUN.Servers
+	if len(servers) == 0 {
+		servers = defaultSTUNServers
+	}
+
+	if strings.ToUpper(helper.configuration.IPType) == utils.IPV6 {
+		network = "udp6"
+		servers = helper.configuration.STUN.IPV6Servers
+		if len(servers) == 0 {
+			servers = defaultIPV6STUNServers
+		}
+	}
+
+	for _, server := range servers {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		ip, err := helper.querySTUN(ctx, network, server)
+		if err != nil {
+			log.Printf("STUN request to %s failed: %s", server, err)
+			continue
+		}
+
+		log.Printf("Get ip success by STUN server: %s, IP: %s", server, ip)
+		return ip.String(), nil
+	}
+
+	return "", errors.New("can't get a valid address from the STUN servers")
+}
+
+func (helper *IPHelper) querySTUN(ctx context.Context, network, server string) (net.IP, error) {
+	dialer, err := utils.NewDialer(helper.bind, network)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var tid [12]byte
+	if _, err = rand.Read(tid[:]); err != nil {
+		return nil, err
+	}
+
+	if err = conn.SetDeadline(time.Now().Add(stunTimeout)); err != nil {
+		return nil, err
+	}
+
+	if _, err = conn.Write(newSTUNRequest(tid)); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1500)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	ip, err := parseSTUNResponse(buf[:n], tid)
+	if err != nil {
+		return nil, err
+	}
+
+	if (ip.To4() == nil) != (network == "udp6") {
+		return nil, errors.New("STUN server returned an address of the wrong family: " + ip.String())
+	}
+
+	return ip, nil
+}
+
+// newSTUNRequest builds the binding request without attributes.
+func newSTUNRequest(tid [12]byte) []byte {
+	msg := make([]byte, stunHeaderLength)
+	binary.BigEndian.PutUint16(msg[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint16(msg[2:4], 0)
+	binary.BigEndian.PutUint32(msg[4:8], stunMagicCookie)
+	copy(msg[8:20], tid[:])
+	return msg
+}
+
+// parseSTUNResponse returns the mapped address from the binding success response,
+// XOR-MAPPED-ADDRESS is preferred over MAPPED-ADDRESS.
+func parseSTUNResponse(msg []byte, tid [12]byte) (net.IP, error) {
+	if len(msg) < stunHeaderLength {
+		return nil, errors.New("STUN response is too short")
+	}
+
+	if binary.BigEndian.Uint16(msg[0:2]) != stunBindingSuccess {
+		return nil, errors.New("STUN response is not a binding success")
+	}
+
+	if binary.BigEndian.Uint32(msg[4:8]) != stunMagicCookie || !bytes.Equal(msg[8:20], tid[:]) {
+		return nil, errors.New("STUN response does not match the request")
+	}
+
+	length := int(binary.BigEndian.Uint16(msg[2:4]))
+	if stunHeaderLength+length > len(msg) {
+		return nil, errors.New("STUN response is truncated")
+	}
+
+	var mapped net.IP
+	attrs := msg[stunHeaderLength : stunHeaderLength+length]
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if 4+attrLen > len(attrs) {
+			return nil, errors.New("STUN attribute is truncated")
+		}
+
+		value := attrs[4 : 4+attrLen]
+		switch attrType {
+		case stunAttrXORMappedAddress, stunAttrXORMappedOld:
+			return parseSTUNAddress(value, msg[4:20])
+		case stunAttrMappedAddress:
+			mapped, _ = parseSTUNAddress(value, nil)
+		}
+
+		// attributes are padded to a multiple of 4 bytes
+		padded := (attrLen + 3) &^ 3
+		if 4+padded > len(attrs) {
+			break
+		}
+		attrs = attrs[4+padded:]
+	}
+
+	if mapped != nil {
+		return mapped, nil
+	}
+
+	return nil, errors.New("STUN response has no mapped address")
+}
+
+// parseSTUNAddress parses the address attribute value,
+// the address is XOR-ed with the magic cookie and the transaction ID if key is set.
+func parseSTUNAddress(value, key []byte) (net.IP, error) {
+	if len(value) < 4 {
+		return nil, errors.New("STUN address attribute is too short")
+	}
+
+	var size int
+	switch value[1] {
+	case stunFamilyIPv4:
+		size = net.IPv4len
+	case stunFamilyIPv6:
+		size = net.IPv6len
+	default:
+		return nil, errors.New("unknown STUN address family")
+	}
+
+	if len(value) < 4+size {
+		return nil, errors.New("STUN address attribute is too short")
+	}
+
+	ip := make(net.IP, size)
+	copy(ip, value[4:4+size])
+	for i := range key {
+		if i < size {
+			ip[i] ^= key[i]
+		}
+	}
+
+	return ip, nil
+}
@@ -0,0 +1,104 @@
+package ip
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+// newSTUNResponse builds the binding success response with XOR-MAPPED-ADDRESS.
+func newSTUNResponse(request []byte, addr *net.UDPAddr) []byte {
+	family, ip := byte(stunFamilyIPv6), addr.IP.To16()
+	if ip4 := addr.IP.To4(); ip4 != nil {
+		family, ip = stunFamilyIPv4, ip4
+	}
+
+	key := request[4:20]
+	value := make([]byte, 4+len(ip))
+	value[1] = family
+	binary.BigEndian.PutUint16(value[2:4], uint16(addr.Port)^uint16(stunMagicCookie>>16))
+	for i := range ip {
+		value[4+i] = ip[i] ^ key[i]
+	}
+
+	// an unknown attribute goes first to check that it is skipped
+	attrs := []byte{0x80, 0x22, 0x00, 0x03, 'g', 'o', 'd', 0x00}
+	attrs = binary.BigEndian.AppendUint16(attrs, stunAttrXORMappedAddress)
+	attrs = binary.BigEndian.AppendUint16(attrs, uint16(len(value)))
+	attrs = append(attrs, value...)
+
+	msg := make([]byte, stunHeaderLength, stunHeaderLength+len(attrs))
+	binary.BigEndian.PutUint16(msg[0:2], stunBindingSuccess)
+	binary.BigEndian.PutUint16(msg[2:4], uint16(len(attrs)))
+	copy(msg[4:20], request[4:20])
+	return append(msg, attrs...)
+}
+
+// startSTUNServer starts a local STUN responder.
+func startSTUNServer(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+
+			if n >= stunHeaderLength && binary.BigEndian.Uint16(buf[0:2]) == stunBindingRequest {
+				_, _ = conn.WriteToUDP(newSTUNResponse(buf[:n], addr), addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestGetIPFromSTUN(t *testing.T) {
+	addr := startSTUNServer(t)
+	helper := &IPHelper{configuration: &settings.Settings{
+		IPType: utils.IPV4,
+		STUN: settings.STUN{
+			Enabled: true,
+			Servers: []string{"127.0.0.1:1", addr},
+		},
+	}}
+
+	ip, err := helper.getIPFromSTUN(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ip != "127.0.0.1" {
+		t.Errorf("expected 127.0.0.1, got %s", ip)
+	}
+}
+
+func TestParseSTUNResponseIPv6(t *testing.T) {
+	var tid [12]byte
+	copy(tid[:], "goddns-stun!")
+	expected := &net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 4242}
+	ip, err := parseSTUNResponse(newSTUNResponse(newSTUNRequest(tid), expected), tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ip.Equal(expected.IP) {
+		t.Errorf("expected %s, got %s", expected.IP, ip)
+	}
+
+	var other [12]byte
+	if _, err = parseSTUNResponse(newSTUNResponse(newSTUNRequest(tid), expected), other); err == nil {
+		t.Error("response to another transaction should be rejected")
+	}
+}
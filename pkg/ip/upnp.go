@@ -0,0 +1,275 @@
+package ip
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
+)
+
+const (
+	ssdpAddr    = "239.255.255.250:1900"
+	ssdpTimeout = 3 * time.Second
+	igdDevice   = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+)
+
+// WAN connection services which provide GetExternalIPAddress.
+var igdServices = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:2",
+	"urn:schemas-upnp-org:service:WANIPConnection:1",
+	"urn:schemas-upnp-org:service:WANPPPConnection:1",
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+type upnpDevice struct {
+	DeviceType string        `xml:"deviceType"`
+	Services   []upnpService `xml:"serviceList>service"`
+	Devices    []upnpDevice  `xml:"deviceList>device"`
+}
+
+type upnpRoot struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+type upnpExternalIPResponse struct {
+	IP string `xml:"Body>GetExternalIPAddressResponse>NewExternalIPAddress"`
+}
+
+// getIPFromUPnP gets WAN IP from the router with UPnP IGD.
+// The device description is discovered with SSDP unless the gateway URL is set.
+func (helper *IPHelper) getIPFromUPnP(ctx context.Context) (string, error) {
+	if strings.ToUpper(helper.configuration.IPType) == utils.IPV6 {
+		return "", errors.New("UPnP IGD only reports IPv4 addresses")
+	}
+
+	location := helper.configuration.UPnP.GatewayURL
+	if location == "" {
+		var err error
+		if location, err = discoverIGD(ctx, helper.bind); err != nil {
+			return "", err
+		}
+	}
+
+	client, err := helper.newGatewayClient()
+	if err != nil {
+		return "", err
+	}
+
+	serviceType, controlURL, err := getIGDService(ctx, client, location)
+	if err != nil {
+		return "", err
+	}
+
+	ip, err := getIGDExternalIP(ctx, client, serviceType, controlURL)
+	if err != nil {
+		return "", err
+	}
+
+	if net.ParseIP(ip) == nil || !isIPv4(ip) {
+		return "", errors.New("UPnP gateway returned invalid address: " + ip)
+	}
+
+	log.Printf("Get ip success by UPnP IGD: %s, IP: %s", controlURL, ip)
+	return ip, nil
+}
+
+// newGatewayClient returns the client for the gateway on LAN which connects
+// through the binding of the helper, the proxy is not used.
+func (helper *IPHelper) newGatewayClient() (*http.Client, error) {
+	transport, err := utils.NewTransport(helper.configuration, utils.ComponentIPDetection)
+	if err != nil {
+		return nil, err
+	}
+
+	transport.Proxy = nil
+	transport.DialContext = utils.BindDialContext(helper.bind)
+	return &http.Client{
+		Timeout:   time.Second * utils.DefaultTimeout,
+		Transport: transport,
+	}, nil
+}
+
+// discoverIGD searches for the internet gateway device with SSDP
+// from the bound address and returns the location of its description.
+func discoverIGD(ctx context.Context, bind settings.Bind) (string, error) {
+	dialer, err := utils.NewDialer(bind, "udp4")
+	if err != nil {
+		return "", err
+	}
+
+	local := ":0"
+	if dialer.LocalAddr != nil {
+		local = dialer.LocalAddr.String()
+	}
+
+	lc := net.ListenConfig{Control: dialer.Control}
+	conn, err := lc.ListenPacket(ctx, "udp4", local)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	addr, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return "", err
+	}
+
+	search := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpAddr + "\r\n" +
+		"ST: " + igdDevice + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 2\r\n\r\n"
+	if _, err = conn.WriteTo([]byte(search), addr); err != nil {
+		return "", err
+	}
+
+	if err = conn.SetReadDeadline(time.Now().Add(ssdpTimeout)); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return "", fmt.Errorf("no UPnP gateway found: %w", err)
+		}
+
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+
+		if location := resp.Header.Get("Location"); location != "" {
+			return location, nil
+		}
+	}
+}
+
+// getIGDService returns the type and the control URL of the WAN connection service.
+func getIGDService(ctx context.Context, client *http.Client, location string) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("request %s got httpCode:%d", location, resp.StatusCode)
+	}
+
+	var root upnpRoot
+	if err = xml.NewDecoder(resp.Body).Decode(&root); err != nil {
+		return "", "", err
+	}
+
+	base, err := url.Parse(location)
+	if err != nil {
+		return "", "", err
+	}
+
+	if root.URLBase != "" {
+		if base, err = url.Parse(root.URLBase); err != nil {
+			return "", "", err
+		}
+	}
+
+	for _, serviceType := range igdServices {
+		if service := findUPnPService(&root.Device, serviceType); service != nil {
+			controlURL, err := base.Parse(service.ControlURL)
+			if err != nil {
+				return "", "", err
+			}
+
+			return serviceType, controlURL.String(), nil
+		}
+	}
+
+	return "", "", errors.New("no WAN connection service found in " + location)
+}
+
+func findUPnPService(device *upnpDevice, serviceType string) *upnpService {
+	for i := range device.Services {
+		if device.Services[i].ServiceType == serviceType {
+			return &device.Services[i]
+		}
+	}
+
+	for i := range device.Devices {
+		if service := findUPnPService(&device.Devices[i], serviceType); service != nil {
+			return service
+		}
+	}
+
+	return nil
+}
+
+// getIGDExternalIP calls GetExternalIPAddress of the WAN connection service.
+func getIGDExternalIP(ctx context.Context, client *http.Client, serviceType, controlURL string) (string, error) {
+	req, err := newSOAPRequest(ctx, controlURL, serviceType, "GetExternalIPAddress")
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request %s got httpCode:%d, body: %s", controlURL, resp.StatusCode, string(content))
+	}
+
+	var result upnpExternalIPResponse
+	if err = xml.Unmarshal(content, &result); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(result.IP), nil
+}
+
+// newSOAPRequest builds the request of the SOAP action without arguments.
+func newSOAPRequest(ctx context.Context, controlURL, serviceType, action string) (*http.Request, error) {
+	body := `<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:` + action + ` xmlns:u="` + serviceType + `"/></s:Body>` +
+		`</s:Envelope>`
+	req, err := http.NewRequestWithContext(ctx, "POST", controlURL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+serviceType+`#`+action+`"`)
+	return req, nil
+}
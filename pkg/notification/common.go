@@ -2,6 +2,7 @@ package notification
 
 import (
 	"bytes"
+	"fmt"
 	"log"
 	"text/template"
 )
@@ -28,3 +29,7 @@ func buildTemplate(currentIP, domain string, tplsrc string) string {
 
 	return tpl.String()
 }
+
+func buildEventMessage(event, message string) string {
+	return fmt.Sprintf("GoDDNS %s warning: %s", event, message)
+}
@@ -2,9 +2,12 @@ package notification
 
 import (
 	"errors"
+	"net/http"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
 )
 
 type DiscordNotification struct {
@@ -16,6 +19,19 @@ func NewDiscordNotification(conf *settings.Settings) INotification {
 }
 
 func (n *DiscordNotification) Send(domain, currentIP string) error {
+	tpl := n.conf.Notify.Discord.MsgTemplate
+	if tpl == "" {
+		tpl = "Your IP address for {{.Domain}} has been updated to {{ .CurrentIP }} "
+	}
+
+	return n.deliver(buildTemplate(currentIP, domain, tpl))
+}
+
+func (n *DiscordNotification) SendEvent(event, message string) error {
+	return n.deliver(buildEventMessage(event, message))
+}
+
+func (n *DiscordNotification) deliver(msg string) error {
 	if n.conf.Notify.Discord.BotAPIToken == "" {
 		return errors.New("bot api token cannot be empty")
 	}
@@ -24,19 +40,25 @@ func (n *DiscordNotification) Send(domain, currentIP string) error {
 		return errors.New("channel id cannot be empty")
 	}
 
-	tpl := n.conf.Notify.Discord.MsgTemplate
-	if tpl == "" {
-		tpl = "Your IP address for {{.Domain}} has been updated to {{ .CurrentIP }} "
-	}
-
-	msg := buildTemplate(currentIP, domain, tpl)
-
 	// create discordgo client
 	d, err := discordgo.New("Bot " + n.conf.Notify.Discord.BotAPIToken)
 	if err != nil {
 		return errors.New("error creating discord bot")
 	}
 
+	// route the REST and the gateway connections the same way as other notifications
+	transport, err := utils.NewTransport(n.conf, utils.ComponentNotification)
+	if err != nil {
+		return err
+	}
+
+	d.Client = &http.Client{Timeout: time.Second * utils.DefaultTimeout, Transport: transport}
+	dialer := *d.Dialer
+	dialer.TLSClientConfig = transport.TLSClientConfig
+	dialer.Proxy = transport.Proxy
+	dialer.NetDialContext = transport.DialContext
+	d.Dialer = &dialer
+
 	// open socket connection
 	if err = d.Open(); err != nil {
 		return errors.New("error opening connection,")
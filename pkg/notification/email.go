@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/pchchv/goddns/internal/settings"
+	"github.com/pchchv/goddns/internal/utils"
 	"gopkg.in/gomail.v2"
 )
 
@@ -119,6 +120,16 @@ func NewEmailNotification(conf *settings.Settings) INotification {
 }
 
 func (n *EmailNotification) Send(domain, currentIP string) error {
+	log.Println("currentIP:", currentIP)
+	log.Println("domain:", domain)
+	return n.deliver("GoDDNS Notification", "text/html", buildTemplate(currentIP, domain, mailTemplate))
+}
+
+func (n *EmailNotification) SendEvent(event, message string) error {
+	return n.deliver("GoDDNS Notification: "+event, "text/plain", buildEventMessage(event, message))
+}
+
+func (n *EmailNotification) deliver(subject, contentType, body string) error {
 	log.Println("Sending notification to: ", n.conf.Notify.Mail.SendTo)
 
 	m := gomail.NewMessage()
@@ -130,10 +141,8 @@ func (n *EmailNotification) Send(domain, currentIP string) error {
 	}
 
 	m.SetHeader("To", n.conf.Notify.Mail.SendTo)
-	m.SetHeader("Subject", "GoDDNS Notification")
-	log.Println("currentIP:", currentIP)
-	log.Println("domain:", domain)
-	m.SetBody("text/html", buildTemplate(currentIP, domain, mailTemplate))
+	m.SetHeader("Subject", subject)
+	m.SetBody(contentType, body)
 
 	d := gomail.NewDialer(
 		n.conf.Notify.Mail.SMTPServer,
@@ -141,6 +150,14 @@ func (n *EmailNotification) Send(domain, currentIP string) error {
 		n.conf.Notify.Mail.SMTPUsername,
 		n.conf.Notify.Mail.SMTPPassword)
 
+	tlsConfig, err := utils.GetTLSConfig(n.conf)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig.ServerName = n.conf.Notify.Mail.SMTPServer
+	d.TLSConfig = tlsConfig
+
 	// Send the email config by sendlist.
 	return d.DialAndSend(m)
 }
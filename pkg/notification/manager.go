@@ -20,12 +20,21 @@ var (
 	once     sync.Once
 )
 
+// events which are reported apart from the IP changes
+const (
+	EventCGNAT        = "cgnat"
+	EventFlapping     = "flapping"
+	EventVerification = "verification"
+)
+
 type INotification interface {
 	Send(domain, currentIP string) error
+	SendEvent(event, message string) error
 }
 
 type INotificationManager interface {
 	Send(string, string)
+	SendEvent(string, string)
 }
 
 type notificationManager struct {
@@ -40,6 +49,14 @@ func (n *notificationManager) Send(domain, currentIP string) {
 	}
 }
 
+func (n *notificationManager) SendEvent(event, message string) {
+	for _, sender := range n.notifications {
+		if err := sender.SendEvent(event, message); err != nil {
+			log.Printf("Send %s notification with error: %s", event, err)
+		}
+	}
+}
+
 func GetNotificationManager(conf *settings.Settings) INotificationManager {
 	once.Do(func() {
 		instance = &notificationManager{
@@ -22,7 +22,21 @@ func NewPushoverNotification(conf *settings.Settings) INotification {
 	return &PushoverNotification{conf: conf}
 }
 
-func (n *PushoverNotification) Send(domain, currentIP string) (err error) {
+func (n *PushoverNotification) Send(domain, currentIP string) error {
+	tpl := n.conf.Notify.Pushover.MsgTemplate
+	if tpl == "" {
+		tpl = "Your IP address changed to <b>{{ .CurrentIP }}</b>. The DNS record for {{ .Domain }} updated."
+		n.conf.Notify.Pushover.HTML = 1
+	}
+
+	return n.deliver(buildTemplate(currentIP, domain, tpl), n.conf.Notify.Pushover.HTML)
+}
+
+func (n *PushoverNotification) SendEvent(event, message string) error {
+	return n.deliver(buildEventMessage(event, message), 0)
+}
+
+func (n *PushoverNotification) deliver(msg string, html int) (err error) {
 	if n.conf.Notify.Pushover.Token == "" {
 		return errors.New("pushover api token cannot be empty")
 	}
@@ -31,21 +45,17 @@ func (n *PushoverNotification) Send(domain, currentIP string) (err error) {
 		return errors.New("pushover user cannot be empty")
 	}
 
-	client := utils.GetHTTPClient(n.conf)
-	tpl := n.conf.Notify.Pushover.MsgTemplate
-	if tpl == "" {
-		tpl = "Your IP address changed to <b>{{ .CurrentIP }}</b>. The DNS record for {{ .Domain }} updated."
-		n.conf.Notify.Pushover.HTML = 1
+	client, err := utils.GetHTTPClientFor(n.conf, utils.ComponentNotification)
+	if err != nil {
+		return err
 	}
 
 	var response *http.Response
-	msg := buildTemplate(currentIP, domain, tpl)
-
 	form := url.Values{}
 	form.Add("token", n.conf.Notify.Pushover.Token)
 	form.Add("user", n.conf.Notify.Pushover.User)
 	form.Add("message", msg)
-	form.Add("html", strconv.FormatInt(int64(n.conf.Notify.Pushover.HTML), 10))
+	form.Add("html", strconv.FormatInt(int64(html), 10))
 	if n.conf.Notify.Pushover.Device != "" {
 		form.Add("device", n.conf.Notify.Pushover.Device)
 	}
@@ -20,7 +20,20 @@ func NewSlackNotification(conf *settings.Settings) INotification {
 	return &SlackNotification{conf: conf}
 }
 
-func (n *SlackNotification) Send(domain, currentIP string) (err error) {
+func (n *SlackNotification) Send(domain, currentIP string) error {
+	tpl := n.conf.Notify.Slack.MsgTemplate
+	if tpl == "" {
+		tpl = "_Your IP address is changed to_\n\n*{{ .CurrentIP }}*\n\nDomain *{{ .Domain }}* updated"
+	}
+
+	return n.deliver(buildTemplate(currentIP, domain, tpl))
+}
+
+func (n *SlackNotification) SendEvent(event, message string) error {
+	return n.deliver(buildEventMessage(event, message))
+}
+
+func (n *SlackNotification) deliver(msg string) (err error) {
 	if n.conf.Notify.Slack.BotAPIToken == "" {
 		return errors.New("bot api token cannot be empty")
 	}
@@ -29,14 +42,11 @@ func (n *SlackNotification) Send(domain, currentIP string) (err error) {
 		return errors.New("channel cannot be empty")
 	}
 
-	client := utils.GetHTTPClient(n.conf)
-	tpl := n.conf.Notify.Slack.MsgTemplate
-	if tpl == "" {
-		tpl = "_Your IP address is changed to_\n\n*{{ .CurrentIP }}*\n\nDomain *{{ .Domain }}* updated"
+	client, err := utils.GetHTTPClientFor(n.conf, utils.ComponentNotification)
+	if err != nil {
+		return err
 	}
 
-	msg := buildTemplate(currentIP, domain, tpl)
-
 	var response *http.Response
 	formData := url.Values{
 		"token":   {n.conf.Notify.Slack.BotAPIToken},
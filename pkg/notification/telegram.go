@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/pchchv/goddns/internal/settings"
 	"github.com/pchchv/goddns/internal/utils"
@@ -19,7 +20,21 @@ func NewTelegramNotification(conf *settings.Settings) INotification {
 	return &TelegramNotification{conf: conf}
 }
 
-func (n *TelegramNotification) Send(domain, currentIP string) (err error) {
+func (n *TelegramNotification) Send(domain, currentIP string) error {
+	tpl := n.conf.Notify.Telegram.MsgTemplate
+	if tpl == "" {
+		tpl = "_Your IP address is changed to_%0A%0A*{{ .CurrentIP }}*%0A%0ADomain *{{ .Domain }}* updated"
+	}
+
+	return n.deliver(buildTemplate(currentIP, domain, tpl))
+}
+
+func (n *TelegramNotification) SendEvent(event, message string) error {
+	return n.deliver(url.QueryEscape(buildEventMessage(event, message)))
+}
+
+// deliver sends the URL encoded message.
+func (n *TelegramNotification) deliver(msg string) (err error) {
 	if n.conf.Notify.Telegram.BotAPIKey == "" {
 		return errors.New("bot api key cannot be empty")
 	}
@@ -28,14 +43,12 @@ func (n *TelegramNotification) Send(domain, currentIP string) (err error) {
 		return errors.New("chat id cannot be empty")
 	}
 
-	client := utils.GetHTTPClient(n.conf)
-	tpl := n.conf.Notify.Telegram.MsgTemplate
-	if tpl == "" {
-		tpl = "_Your IP address is changed to_%0A%0A*{{ .CurrentIP }}*%0A%0ADomain *{{ .Domain }}* updated"
+	client, err := utils.GetHTTPClientFor(n.conf, utils.ComponentNotification)
+	if err != nil {
+		return err
 	}
 
 	var response *http.Response
-	msg := buildTemplate(currentIP, domain, tpl)
 	reqURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage?chat_id=%s&parse_mode=Markdown&text=%s",
 		n.conf.Notify.Telegram.BotAPIKey,
 		n.conf.Notify.Telegram.ChatID,
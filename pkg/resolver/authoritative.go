@@ -0,0 +1,272 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// maxReferrals limits the depth of the walk from the root.
+const maxReferrals = 16
+
+var (
+	// rootServers are the addresses of a part of the root servers
+	// used to walk to the zone when no resolver is set.
+	// Both families are listed so that the walk works on IPv6-only hosts.
+	rootServers = []string{
+		"198.41.0.4", "2001:503:ba3e::2:30", // a.root-servers.net
+		"192.33.4.12", "2001:500:2::c", // c.root-servers.net
+		"192.5.5.241", "2001:500:2f::f", // f.root-servers.net
+		"193.0.14.129", "2001:7fd::1", // k.root-servers.net
+	}
+	// authoritativePort is the port of the root and the authoritative servers.
+	authoritativePort = "53"
+)
+
+// nameServer is the authoritative server of the zone with its addresses.
+type nameServer struct {
+	name  string
+	addrs []string
+}
+
+// LookupAuthoritative returns the addresses of the host from every authoritative
+// name server of its zone, queried directly with RD=0, by the name of the server.
+// The name servers of the zone are found with the resolver servers,
+// or by walking from the root if there are none.
+func (r *DNSResolver) LookupAuthoritative(host string, dnsType uint16) (map[string][]net.IP, error) {
+	var servers []nameServer
+	var err error
+	if len(r.Servers) > 0 {
+		servers, err = r.findNameServers(host)
+	} else {
+		servers, _, err = r.walk(host, dnsType, 0)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	answers := map[string][]net.IP{}
+	var errs []error
+	for _, ns := range servers {
+		in, err := r.queryAny(ns.addrs, host, dnsType)
+		if err == nil && !in.Authoritative {
+			err = errors.New("answer is not authoritative")
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ns.name, err))
+			continue
+		}
+
+		answers[ns.name] = answerIPs(in, dnsType)
+	}
+
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("no authoritative server of %s answered: %w", host, errors.Join(errs...))
+	}
+
+	return answers, nil
+}
+
+// findNameServers finds the zone of the host with the resolver and returns its name servers.
+func (r *DNSResolver) findNameServers(host string) ([]nameServer, error) {
+	in, err := r.query(host, dns.TypeSOA)
+	if err != nil {
+		return nil, err
+	}
+
+	var zone string
+	for _, rr := range append(in.Answer, in.Ns...) {
+		if soa, ok := rr.(*dns.SOA); ok {
+			zone = soa.Hdr.Name
+			break
+		}
+	}
+
+	if zone == "" {
+		return nil, errors.New("cannot find the zone of " + host)
+	}
+
+	if in, err = r.query(zone, dns.TypeNS); err != nil {
+		return nil, err
+	}
+
+	servers := nameServers(in.Answer, in.Extra)
+	for i := range servers {
+		if len(servers[i].addrs) > 0 {
+			continue
+		}
+
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			if ips, err := r.LookupIP(servers[i].name, qtype, dns.ClassINET); err == nil {
+				servers[i].addrs = append(servers[i].addrs, joinAddrs(ips)...)
+			}
+		}
+	}
+
+	if len(servers) == 0 {
+		return nil, errors.New("zone " + zone + " has no name servers")
+	}
+
+	return servers, nil
+}
+
+// walk follows the referrals from the root until a server answers authoritatively.
+// It returns the name servers of the zone and the authoritative answer.
+func (r *DNSResolver) walk(host string, dnsType uint16, depth int) ([]nameServer, *dns.Msg, error) {
+	if depth > maxReferrals {
+		return nil, nil, errors.New("too many referrals resolving " + host)
+	}
+
+	addrs := make([]string, 0, len(rootServers))
+	for _, root := range rootServers {
+		addrs = append(addrs, net.JoinHostPort(root, authoritativePort))
+	}
+
+	var servers []nameServer
+	for ; depth <= maxReferrals; depth++ {
+		in, err := r.queryAny(addrs, host, dnsType)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if in.Authoritative {
+			if servers == nil {
+				return nil, nil, errors.New("root servers answered for " + host)
+			}
+			return servers, in, nil
+		}
+
+		if servers = nameServers(in.Ns, in.Extra); len(servers) == 0 {
+			return nil, nil, errors.New("no referral for " + host)
+		}
+
+		addrs = addrs[:0]
+		for i, ns := range servers {
+			// the name servers out of the zone come without glue
+			if len(ns.addrs) == 0 {
+				servers[i].addrs = r.walkAddrs(ns.name, depth+1)
+			}
+			addrs = append(addrs, servers[i].addrs...)
+		}
+	}
+
+	return nil, nil, errors.New("too many referrals resolving " + host)
+}
+
+// walkAddrs resolves the addresses of the name server by walking from the root.
+func (r *DNSResolver) walkAddrs(name string, depth int) []string {
+	var addrs []string
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		if _, in, err := r.walk(name, qtype, depth); err == nil {
+			addrs = append(addrs, joinAddrs(answerIPs(in, qtype))...)
+		}
+	}
+	return addrs
+}
+
+// query sends the recursive query to the resolver servers.
+func (r *DNSResolver) query(name string, qtype uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	in, err := r.exchange(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.Rcode != dns.RcodeSuccess {
+		return nil, errors.New(dns.RcodeToString[in.Rcode])
+	}
+
+	return in, nil
+}
+
+// queryAny sends the non-recursive query to the addresses in order until one of them answers.
+func (r *DNSResolver) queryAny(addrs []string, name string, qtype uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	m.RecursionDesired = false
+
+	err := errors.New("no address to query for " + name)
+	for _, addr := range addrs {
+		var in *dns.Msg
+		client := &dns.Client{Dialer: r.Dialer}
+		if in, _, err = client.Exchange(m, addr); err == nil && in.Truncated {
+			client.Net = "tcp"
+			in, _, err = client.Exchange(m, addr)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if in.Rcode != dns.RcodeSuccess && in.Rcode != dns.RcodeNameError {
+			err = fmt.Errorf("%s answered %s", addr, dns.RcodeToString[in.Rcode])
+			continue
+		}
+
+		return in, nil
+	}
+
+	return nil, err
+}
+
+// nameServers returns the NS records with the addresses from the glue records.
+func nameServers(records, extra []dns.RR) []nameServer {
+	var servers []nameServer
+	for _, rr := range records {
+		ns, ok := rr.(*dns.NS)
+		if !ok {
+			continue
+		}
+
+		server := nameServer{name: ns.Ns}
+		for _, glue := range extra {
+			if !strings.EqualFold(glue.Header().Name, ns.Ns) {
+				continue
+			}
+
+			switch g := glue.(type) {
+			case *dns.A:
+				server.addrs = append(server.addrs, net.JoinHostPort(g.A.String(), authoritativePort))
+			case *dns.AAAA:
+				server.addrs = append(server.addrs, net.JoinHostPort(g.AAAA.String(), authoritativePort))
+			}
+		}
+		servers = append(servers, server)
+	}
+
+	sort.Slice(servers, func(i, j int) bool { return servers[i].name < servers[j].name })
+	return servers
+}
+
+// answerIPs returns the addresses of the type from the answer.
+func answerIPs(in *dns.Msg, dnsType uint16) []net.IP {
+	var ips []net.IP
+	for _, rr := range in.Answer {
+		switch t := rr.(type) {
+		case *dns.A:
+			if dnsType == dns.TypeA {
+				ips = append(ips, t.A)
+			}
+		case *dns.AAAA:
+			if dnsType == dns.TypeAAAA {
+				ips = append(ips, t.AAAA)
+			}
+		}
+	}
+	return ips
+}
+
+func joinAddrs(ips []net.IP) []string {
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.JoinHostPort(ip.String(), authoritativePort))
+	}
+	return addrs
+}
@@ -0,0 +1,92 @@
+package resolver
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// zoneHandler serves example.com: 127.0.0.3 is the root, 127.0.0.1 and 127.0.0.2
+// are the authoritative servers which disagree on the address of www.example.com.
+func zoneHandler(w dns.ResponseWriter, req *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(req)
+	q := req.Question[0]
+	host, _, _ := net.SplitHostPort(w.LocalAddr().String())
+	glue := []dns.RR{
+		&dns.A{Hdr: dns.RR_Header{Name: "ns1.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.ParseIP("127.0.0.1")},
+		&dns.A{Hdr: dns.RR_Header{Name: "ns2.example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.ParseIP("127.0.0.2")},
+	}
+	ns := []dns.RR{
+		&dns.NS{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 60}, Ns: "ns1.example.com."},
+		&dns.NS{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 60}, Ns: "ns2.example.com."},
+	}
+
+	switch {
+	case w.LocalAddr().String() == recursiveAddr:
+		// the recursive resolver
+		if q.Qtype == dns.TypeSOA {
+			m.Ns = []dns.RR{&dns.SOA{Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 60}, Ns: "ns1.example.com.", Mbox: "hostmaster.example.com."}}
+		} else if q.Qtype == dns.TypeNS {
+			m.Answer, m.Extra = ns, glue
+		}
+	case req.RecursionDesired:
+		m.Rcode = dns.RcodeRefused
+	case host == "127.0.0.3":
+		m.Ns, m.Extra = ns, glue
+	default:
+		m.Authoritative = true
+		ip := map[string]string{"127.0.0.1": "203.0.113.1", "127.0.0.2": "203.0.113.2"}[host]
+		m.Answer = []dns.RR{&dns.A{Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.ParseIP(ip)}}
+	}
+
+	_ = w.WriteMsg(m)
+}
+
+var recursiveAddr string
+
+func startZone(t *testing.T) {
+	t.Helper()
+	serve := func(addr string) string {
+		conn, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			t.Skip("cannot listen on", addr, err)
+		}
+
+		server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(zoneHandler)}
+		go func() { _ = server.ActivateAndServe() }()
+		t.Cleanup(func() { _ = server.Shutdown() })
+		return conn.LocalAddr().String()
+	}
+
+	recursiveAddr = serve("127.0.0.1:0")
+	_, port, _ := net.SplitHostPort(serve("127.0.0.3:0"))
+	serve("127.0.0.1:" + port)
+	serve("127.0.0.2:" + port)
+
+	roots, authPort := rootServers, authoritativePort
+	rootServers, authoritativePort = []string{"127.0.0.3"}, port
+	t.Cleanup(func() { rootServers, authoritativePort = roots, authPort })
+}
+
+func TestLookupAuthoritative(t *testing.T) {
+	startZone(t)
+	expected := map[string][]net.IP{
+		"ns1.example.com.": {net.ParseIP("203.0.113.1").To4()},
+		"ns2.example.com.": {net.ParseIP("203.0.113.2").To4()},
+	}
+
+	// with the resolver and by walking from the root
+	for _, servers := range [][]string{{recursiveAddr}, nil} {
+		answers, err := New(servers).LookupAuthoritative("www.example.com", dns.TypeA)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(answers, expected) {
+			t.Errorf("%v: expected %v, got %v", servers, expected, answers)
+		}
+	}
+}
@@ -3,10 +3,14 @@
 package resolver
 
 import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"math/rand"
+	"io"
 	"net"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -14,19 +18,67 @@ import (
 	"github.com/miekg/dns"
 )
 
+const (
+	dohMediaType = "application/dns-message"
+	dohTimeout   = 5 * time.Second
+)
+
 type DNSResolver struct {
 	Servers    []string
 	RetryTimes int
-	r          *rand.Rand
+	Net        string      // network used for the plain servers, "udp" if empty
+	Dialer     *net.Dialer // dialer used for queries, the default one if nil
+	TLSConfig  *tls.Config // TLS config of DNS-over-TLS and DNS-over-HTTPS, the default one if nil
 }
 
 // New initializes DnsResolver.
+// The servers are tried in order, each one is either an address
+// of the plain DNS server or an URL:
+//
+//	udp://host[:port], tcp://host[:port] - DNS over UDP or TCP, port 53 by default
+//	tls://host[:port]                    - DNS-over-TLS, port 853 by default
+//	https://host/dns-query               - DNS-over-HTTPS with POST
+//	https://host/dns-query{?dns}         - DNS-over-HTTPS with GET
+//
+// Port 53 is used for the plain servers without port.
 func New(servers []string) *DNSResolver {
-	for i := range servers {
-		servers[i] = net.JoinHostPort(servers[i], "53")
+	normalized := make([]string, 0, len(servers))
+	for _, server := range servers {
+		normalized = append(normalized, normalizeServer(server))
+	}
+
+	return &DNSResolver{Servers: normalized, RetryTimes: len(servers) * 2}
+}
+
+// normalizeServer adds the default port to the server address.
+func normalizeServer(server string) string {
+	scheme, addr, ok := strings.Cut(server, "://")
+	if !ok {
+		scheme, addr = "", server
+	}
+
+	switch scheme {
+	case "https":
+		return server
+	case "tls":
+		addr = withDefaultPort(addr, "853")
+	default:
+		addr = withDefaultPort(addr, "53")
+	}
+
+	if scheme == "" {
+		return addr
 	}
 
-	return &DNSResolver{servers, len(servers) * 2, rand.New(rand.NewSource(time.Now().UnixNano()))}
+	return scheme + "://" + addr
+}
+
+func withDefaultPort(addr, port string) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+
+	return net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"), port)
 }
 
 // NewFromResolvConf initializes DnsResolver from resolv.conf like file.
@@ -45,7 +97,7 @@ func NewFromResolvConf(path string) (*DNSResolver, error) {
 		servers = append(servers, net.JoinHostPort(ipAddress, "53"))
 	}
 
-	return &DNSResolver{servers, len(servers) * 2, rand.New(rand.NewSource(time.Now().UnixNano()))}, err
+	return &DNSResolver{Servers: servers, RetryTimes: len(servers) * 2}, err
 }
 
 // LookupHost returns IP addresses of provided host.
@@ -66,7 +118,7 @@ func (r *DNSResolver) lookupHost(host string, dnsType uint16, triesLeft int) (re
 		m1.Question[0] = dns.Question{Name: dns.Fqdn(host), Qtype: dns.TypeAAAA, Qclass: dns.ClassINET}
 	}
 
-	in, err := dns.Exchange(m1, r.Servers[r.r.Intn(len(r.Servers))])
+	in, err := r.exchange(m1)
 	if err != nil {
 		if strings.HasSuffix(err.Error(), "i/o timeout") && triesLeft > 0 {
 			triesLeft--
@@ -105,3 +157,148 @@ func (r *DNSResolver) lookupHost(host string, dnsType uint16, triesLeft int) (re
 
 	return
 }
+
+// LookupIP returns IP addresses from the answer to the query of the type and class.
+// Besides A and AAAA records, addresses are parsed from TXT records,
+// which is how some services report the address of the client.
+func (r *DNSResolver) LookupIP(name string, qtype, qclass uint16) ([]net.IP, error) {
+	return r.lookupIP(name, qtype, qclass, r.RetryTimes)
+}
+
+func (r *DNSResolver) lookupIP(name string, qtype, qclass uint16, triesLeft int) (result []net.IP, err error) {
+	m := new(dns.Msg)
+	m.Id = dns.Id()
+	m.RecursionDesired = true
+	m.Question = []dns.Question{{Name: dns.Fqdn(name), Qtype: qtype, Qclass: qclass}}
+
+	in, err := r.exchange(m)
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "i/o timeout") && triesLeft > 0 {
+			triesLeft--
+			return r.lookupIP(name, qtype, qclass, triesLeft)
+		}
+		return result, err
+	}
+
+	if in.Rcode != dns.RcodeSuccess {
+		return result, errors.New(dns.RcodeToString[in.Rcode])
+	}
+
+	for _, record := range in.Answer {
+		switch t := record.(type) {
+		case *dns.A:
+			result = append(result, t.A)
+		case *dns.AAAA:
+			result = append(result, t.AAAA)
+		case *dns.TXT:
+			for _, txt := range t.Txt {
+				if ip := net.ParseIP(strings.TrimSpace(txt)); ip != nil {
+					result = append(result, ip)
+				}
+			}
+		}
+	}
+
+	if len(result) == 0 {
+		return result, errors.New("empty result")
+	}
+
+	return
+}
+
+// exchange sends the query to the servers in order until one of them answers.
+func (r *DNSResolver) exchange(m *dns.Msg) (in *dns.Msg, err error) {
+	if len(r.Servers) == 0 {
+		return nil, errors.New("no DNS server is set")
+	}
+
+	for _, server := range r.Servers {
+		if in, err = r.exchangeWith(m, server); err == nil && in.Rcode != dns.RcodeServerFailure && in.Rcode != dns.RcodeRefused {
+			return in, nil
+		}
+	}
+
+	return in, err
+}
+
+func (r *DNSResolver) exchangeWith(m *dns.Msg, server string) (*dns.Msg, error) {
+	network, addr := "udp", server
+	if scheme, rest, ok := strings.Cut(server, "://"); ok {
+		switch scheme {
+		case "https":
+			return r.exchangeHTTPS(m, server)
+		case "tls":
+			network, addr = "tcp-tls", rest
+		case "tcp", "udp":
+			network, addr = scheme, rest
+		default:
+			return nil, errors.New("unsupported DNS server: " + server)
+		}
+	} else if r.Net != "" {
+		network = r.Net
+	}
+
+	if network == "udp" && r.Dialer == nil {
+		return dns.Exchange(m, addr)
+	}
+
+	client := &dns.Client{Net: network, Dialer: r.Dialer, TLSConfig: r.TLSConfig}
+	in, _, err := client.Exchange(m, addr)
+	return in, err
+}
+
+// exchangeHTTPS sends the query with DNS-over-HTTPS (RFC 8484).
+func (r *DNSResolver) exchangeHTTPS(m *dns.Msg, server string) (*dns.Msg, error) {
+	// the zero ID makes the responses cacheable
+	query := m.Copy()
+	query.Id = 0
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	var req *http.Request
+	if endpoint, ok := strings.CutSuffix(server, "{?dns}"); ok {
+		req, err = http.NewRequest("GET", endpoint+"?dns="+base64.RawURLEncoding.EncodeToString(packed), nil)
+	} else {
+		req, err = http.NewRequest("POST", server, bytes.NewReader(packed))
+		if err == nil {
+			req.Header.Set("Content-Type", dohMediaType)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", dohMediaType)
+
+	transport := &http.Transport{TLSClientConfig: r.TLSConfig, ForceAttemptHTTP2: true}
+	if r.Dialer != nil {
+		transport.DialContext = r.Dialer.DialContext
+	}
+	defer transport.CloseIdleConnections()
+
+	client := &http.Client{Timeout: dohTimeout, Transport: transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS-over-HTTPS request to %s got httpCode:%d", server, resp.StatusCode)
+	}
+
+	in := new(dns.Msg)
+	if err = in.Unpack(body); err != nil {
+		return nil, err
+	}
+
+	in.Id = m.Id
+	return in, nil
+}
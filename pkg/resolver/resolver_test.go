@@ -1,7 +1,14 @@
 package resolver
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
 	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
@@ -17,6 +24,15 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewWithPort(t *testing.T) {
+	servers := []string{"127.0.0.1:5353", "2001:4860:4860::8888"}
+	expectedServers := []string{"127.0.0.1:5353", "[2001:4860:4860::8888]:53"}
+	resolver := New(servers)
+	if !reflect.DeepEqual(resolver.Servers, expectedServers) {
+		t.Error("resolver.Servers: ", resolver.Servers, "should be equal to", expectedServers)
+	}
+}
+
 func TestLookupHost_ValidServer(t *testing.T) {
 	resolver := New([]string{"8.8.8.8", "8.8.4.4"})
 	if result, err := resolver.LookupHost("google-public-dns-a.google.com", dns.TypeA); err != nil {
@@ -37,3 +53,89 @@ func TestLookupHostIPv6_ValidServer(t *testing.T) {
 		t.Error("result should be: 2001:4860:4860::8888")
 	}
 }
+
+// answerA answers every A query with 203.0.113.1.
+func answerA(req *dns.Msg) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(req)
+	m.Answer = append(m.Answer, &dns.A{
+		Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+		A:   net.ParseIP("203.0.113.1"),
+	})
+	return m
+}
+
+// startDoHServer starts an in-process DNS-over-HTTPS server.
+func startDoHServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var packed []byte
+		var err error
+		if r.Method == http.MethodGet {
+			packed, err = base64.RawURLEncoding.DecodeString(r.URL.Query().Get("dns"))
+		} else {
+			packed, err = io.ReadAll(r.Body)
+		}
+
+		req := new(dns.Msg)
+		if err != nil || r.URL.Path != "/dns-query" || req.Unpack(packed) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		reply, _ := answerA(req).Pack()
+		w.Header().Set("Content-Type", "application/dns-message")
+		_, _ = w.Write(reply)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// startDoTServer starts an in-process DNS-over-TLS server with the certificate of the DoH server.
+func startDoTServer(t *testing.T, doh *httptest.Server) string {
+	t.Helper()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: doh.TLS.Certificates})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &dns.Server{Listener: listener, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		_ = w.WriteMsg(answerA(req))
+	})}
+	go func() { _ = server.ActivateAndServe() }()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return listener.Addr().String()
+}
+
+func TestLookupHostEncrypted(t *testing.T) {
+	doh := startDoHServer(t)
+	dot := startDoTServer(t, doh)
+	roots := x509.NewCertPool()
+	roots.AddCert(doh.Certificate())
+
+	for _, servers := range [][]string{
+		{doh.URL + "/dns-query"},
+		{doh.URL + "/dns-query{?dns}"},
+		{"tls://" + dot},
+		// the unreachable server fails over to the next one
+		{"tcp://127.0.0.1:1", doh.URL + "/dns-query"},
+	} {
+		resolver := New(servers)
+		resolver.TLSConfig = &tls.Config{RootCAs: roots}
+		result, err := resolver.LookupHost("example.com", dns.TypeA)
+		if err != nil {
+			t.Errorf("%v: %s", servers, err)
+		} else if result[0].String() != "203.0.113.1" {
+			t.Errorf("%v: expected 203.0.113.1, got %s", servers, result[0])
+		}
+	}
+}
+
+func TestNewURLs(t *testing.T) {
+	servers := []string{"tls://1.1.1.1", "tcp://[2001:4860:4860::8888]", "https://dns.google/dns-query"}
+	expectedServers := []string{"tls://1.1.1.1:853", "tcp://[2001:4860:4860::8888]:53", "https://dns.google/dns-query"}
+	resolver := New(servers)
+	if !reflect.DeepEqual(resolver.Servers, expectedServers) {
+		t.Error("resolver.Servers: ", resolver.Servers, "should be equal to", expectedServers)
+	}
+}
@@ -19,32 +19,55 @@ var (
 )
 
 type Webhook struct {
-	conf   *settings.Settings
-	client *http.Client
+	conf      *settings.Settings
+	client    *http.Client
+	clientErr error
 }
 
-func (w *Webhook) Execute(domain, currentIP string) (err error) {
-	if w.conf.Webhook.URL == "" {
+// requestData is the data of the request URL and body templates.
+type requestData struct {
+	CurrentIP string
+	Domain    string
+	IPType    string
+	Status    string // status of the update, empty when the record is just updated
+}
+
+func (w *Webhook) Execute(domain, currentIP string) error {
+	return w.execute(w.conf.Webhook, requestData{CurrentIP: currentIP, Domain: domain, IPType: w.conf.IPType})
+}
+
+// ExecuteStatus sends the status of the update of the domain after it is verified
+// to the URL of the status webhook of the verification.
+func (w *Webhook) ExecuteStatus(domain, currentIP, status string) error {
+	return w.execute(w.conf.Verify.Webhook, requestData{CurrentIP: currentIP, Domain: domain, IPType: w.conf.IPType, Status: status})
+}
+
+func (w *Webhook) execute(hook settings.Webhook, data requestData) (err error) {
+	if hook.URL == "" {
 		log.Print("Webhook URL is empty, skip sending notification")
 		return nil
 	}
 
+	if w.clientErr != nil {
+		return w.clientErr
+	}
+
 	// set request method
 	method := http.MethodGet
-	if w.conf.Webhook.RequestBody != "" {
+	if hook.RequestBody != "" {
 		method = http.MethodPost
 	}
 
 	// send HTTP get request
 	var reqURL, reqBody string
 	if method == http.MethodGet {
-		reqURL, err = w.buildReqURL(domain, currentIP, w.conf.IPType)
+		reqURL, err = w.buildReqURL(hook, data)
 		if err != nil {
 			return err
 		}
 	} else {
-		reqURL = w.conf.Webhook.URL
-		reqBody, err = w.buildReqBody(domain, currentIP, w.conf.IPType)
+		reqURL = hook.URL
+		reqBody, err = w.buildReqBody(hook, data)
 		if err != nil {
 			return err
 		}
@@ -77,23 +100,13 @@ func (w *Webhook) Execute(domain, currentIP string) (err error) {
 	return nil
 }
 
-func (w *Webhook) buildReqBody(domain, currentIP, ipType string) (string, error) {
+func (w *Webhook) buildReqBody(hook settings.Webhook, data requestData) (string, error) {
 	t := template.New("reqBody template")
-	if _, err := t.Parse(w.conf.Webhook.RequestBody); err != nil {
+	if _, err := t.Parse(hook.RequestBody); err != nil {
 		log.Fatal("Failed to parse template:", err)
 		return "", err
 	}
 
-	data := struct {
-		CurrentIP string
-		Domain    string
-		IPType    string
-	}{
-		currentIP,
-		domain,
-		ipType,
-	}
-
 	var tpl bytes.Buffer
 	if err := t.Execute(&tpl, data); err != nil {
 		log.Fatal(err)
@@ -103,23 +116,13 @@ func (w *Webhook) buildReqBody(domain, currentIP, ipType string) (string, error)
 	return tpl.String(), nil
 }
 
-func (w *Webhook) buildReqURL(domain, currentIP, ipType string) (string, error) {
+func (w *Webhook) buildReqURL(hook settings.Webhook, data requestData) (string, error) {
 	t := template.New("req template")
-	if _, err := t.Parse(w.conf.Webhook.URL); err != nil {
+	if _, err := t.Parse(hook.URL); err != nil {
 		log.Fatal("Failed to parse template:", err)
 		return "", err
 	}
 
-	data := struct {
-		CurrentIP string
-		Domain    string
-		IPType    string
-	}{
-		currentIP,
-		domain,
-		ipType,
-	}
-
 	var tpl bytes.Buffer
 	if err := t.Execute(&tpl, data); err != nil {
 		log.Fatal(err)
@@ -131,10 +134,8 @@ func (w *Webhook) buildReqURL(domain, currentIP, ipType string) (string, error)
 
 func GetWebhook(conf *settings.Settings) *Webhook {
 	once.Do(func() {
-		instance = &Webhook{
-			conf:   conf,
-			client: utils.GetHTTPClient(conf),
-		}
+		instance = &Webhook{conf: conf}
+		instance.client, instance.clientErr = utils.GetHTTPClientFor(conf, utils.ComponentWebhook)
 	})
 
 	return instance
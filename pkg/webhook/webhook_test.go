@@ -15,7 +15,7 @@ func TestBuildReqBody(t *testing.T) {
 			URL:         "http://localhost:5000/api/v1/send",
 			RequestBody: "{ \"domain\": \"{{.Domain}}\", \"ip\": \"{{.CurrentIP}}\", \"ip_type\": \"{{.IPType}}\" }",
 		}})
-	ret, err := w.buildReqBody("example.com", "192.168.1.1", utils.IPV4)
+	ret, err := w.buildReqBody(w.conf.Webhook, requestData{CurrentIP: "192.168.1.1", Domain: "example.com", IPType: utils.IPV4})
 	if err != nil {
 		t.Error(err)
 	}
@@ -33,7 +33,7 @@ func TestBuildReqURL(t *testing.T) {
 			Enabled: true,
 			URL:     "http://localhost:5000/api/v1/send?domain={{.Domain}}&ip={{.CurrentIP}}&ip_type={{.IPType}}",
 		}})
-	ret, err := w.buildReqURL("example.com", "192.168.1.1", utils.IPV4)
+	ret, err := w.buildReqURL(w.conf.Webhook, requestData{CurrentIP: "192.168.1.1", Domain: "example.com", IPType: utils.IPV4})
 	if err != nil {
 		t.Error(err)
 	}